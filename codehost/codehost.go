@@ -0,0 +1,81 @@
+package codehost
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+)
+
+// PullRequestRef PR во внешней системе
+type PullRequestRef struct {
+	Owner  string
+	Repo   string
+	Number int
+}
+
+func (r PullRequestRef) String() string {
+	return fmt.Sprintf("%s/%s#%d", r.Owner, r.Repo, r.Number)
+}
+
+// Client запрашивает и снимает ревьюверов PR во внешней системе
+type Client interface {
+	RequestReviewers(ctx context.Context, pr PullRequestRef, logins []string) error
+	RemoveReviewers(ctx context.Context, pr PullRequestRef, logins []string) error
+}
+
+var githubIDPattern = regexp.MustCompile(`^([^/\s]+)/([^/#\s]+)#([1-9][0-9]*)$`)
+
+// ParseGitHubID разбирает идентификатор PR, созданного из вебхука GitHub (owner/repo#42)
+func ParseGitHubID(pullRequestID string) (PullRequestRef, bool) {
+	m := githubIDPattern.FindStringSubmatch(pullRequestID)
+	if m == nil {
+		return PullRequestRef{}, false
+	}
+	number, err := strconv.Atoi(m[3])
+	if err != nil {
+		return PullRequestRef{}, false
+	}
+	return PullRequestRef{Owner: m[1], Repo: m[2], Number: number}, true
+}
+
+// StatusError ответ внешней системы с неуспешным кодом
+type StatusError struct {
+	StatusCode int
+	Message    string
+}
+
+func (e *StatusError) Error() string {
+	if e.Message == "" {
+		return fmt.Sprintf("code host returned status %d", e.StatusCode)
+	}
+	return fmt.Sprintf("code host returned status %d: %s", e.StatusCode, e.Message)
+}
+
+// permanentError ошибка, повтор которой не поможет, обнаруженная до обращения к внешней системе
+type permanentError struct {
+	err error
+}
+
+func (e *permanentError) Error() string { return e.err.Error() }
+func (e *permanentError) Unwrap() error { return e.err }
+
+// Permanent помечает ошибку постоянной (см. IsPermanent)
+func Permanent(err error) error {
+	return &permanentError{err: err}
+}
+
+// IsPermanent сообщает, что повтор запроса не поможет: ошибка помечена Permanent или внешняя
+// система отклонила запрос с кодом 4xx (кроме 408 и 429). Сетевые ошибки и 5xx считаются временными
+func IsPermanent(err error) bool {
+	var pe *permanentError
+	if errors.As(err, &pe) {
+		return true
+	}
+	var se *StatusError
+	if !errors.As(err, &se) {
+		return false
+	}
+	return se.StatusCode >= 400 && se.StatusCode < 500 && se.StatusCode != 408 && se.StatusCode != 429
+}
@@ -0,0 +1,80 @@
+package codehost
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// DefaultGitHubURL адрес GitHub REST API по умолчанию
+const DefaultGitHubURL = "https://api.github.com"
+
+// GitHubClient клиент GitHub REST API. Базовый адрес настраивается для GitHub Enterprise
+// и локальных заглушек
+type GitHubClient struct {
+	baseURL string
+	token   string
+	client  *http.Client
+}
+
+func NewGitHubClient(baseURL, token string, client *http.Client) *GitHubClient {
+	if baseURL == "" {
+		baseURL = DefaultGitHubURL
+	}
+	if client == nil {
+		client = &http.Client{Timeout: 10 * time.Second}
+	}
+	return &GitHubClient{baseURL: strings.TrimRight(baseURL, "/"), token: token, client: client}
+}
+
+// RequestReviewers запрашивает ревью у пользователей (POST /repos/{owner}/{repo}/pulls/{number}/requested_reviewers)
+func (c *GitHubClient) RequestReviewers(ctx context.Context, pr PullRequestRef, logins []string) error {
+	return c.requestedReviewers(ctx, http.MethodPost, pr, logins)
+}
+
+// RemoveReviewers отзывает запрос ревью (DELETE /repos/{owner}/{repo}/pulls/{number}/requested_reviewers)
+func (c *GitHubClient) RemoveReviewers(ctx context.Context, pr PullRequestRef, logins []string) error {
+	return c.requestedReviewers(ctx, http.MethodDelete, pr, logins)
+}
+
+func (c *GitHubClient) requestedReviewers(ctx context.Context, method string, pr PullRequestRef, logins []string) error {
+	data, err := json.Marshal(map[string][]string{"reviewers": logins})
+	if err != nil {
+		return err
+	}
+
+	endpoint := fmt.Sprintf("%s/repos/%s/%s/pulls/%d/requested_reviewers",
+		c.baseURL, url.PathEscape(pr.Owner), url.PathEscape(pr.Repo), pr.Number)
+	req, err := http.NewRequestWithContext(ctx, method, endpoint, bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/vnd.github+json")
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-GitHub-Api-Version", "2022-11-28")
+	if c.token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.token)
+	}
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to call GitHub: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		var body struct {
+			Message string `json:"message"`
+		}
+		json.NewDecoder(io.LimitReader(resp.Body, 64<<10)).Decode(&body)
+		return &StatusError{StatusCode: resp.StatusCode, Message: body.Message}
+	}
+	io.Copy(io.Discard, resp.Body)
+	return nil
+}
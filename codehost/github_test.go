@@ -0,0 +1,158 @@
+package codehost
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+// fakeGitHub локальная заглушка GitHub: запоминает последний запрос и отвечает status
+type fakeGitHub struct {
+	status  int
+	message string
+
+	method    string
+	path      string
+	auth      string
+	reviewers []string
+}
+
+func (f *fakeGitHub) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.method, f.path, f.auth = r.Method, r.URL.EscapedPath(), r.Header.Get("Authorization")
+	var body struct {
+		Reviewers []string `json:"reviewers"`
+	}
+	json.NewDecoder(r.Body).Decode(&body)
+	f.reviewers = body.Reviewers
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(f.status)
+	json.NewEncoder(w).Encode(map[string]string{"message": f.message})
+}
+
+func TestGitHubClientRequestedReviewers(t *testing.T) {
+	pr := PullRequestRef{Owner: "acme", Repo: "payments", Number: 42}
+	tests := []struct {
+		name   string
+		call   func(c *GitHubClient) error
+		method string
+		status int
+	}{
+		{"request", func(c *GitHubClient) error {
+			return c.RequestReviewers(context.Background(), pr, []string{"octo-dev"})
+		}, http.MethodPost, http.StatusCreated},
+		{"remove", func(c *GitHubClient) error {
+			return c.RemoveReviewers(context.Background(), pr, []string{"octo-dev"})
+		}, http.MethodDelete, http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fake := &fakeGitHub{status: tt.status}
+			srv := httptest.NewServer(fake)
+			defer srv.Close()
+
+			if err := tt.call(NewGitHubClient(srv.URL+"/", "token-1", nil)); err != nil {
+				t.Fatalf("call: %v", err)
+			}
+			if fake.method != tt.method {
+				t.Errorf("method = %s, want %s", fake.method, tt.method)
+			}
+			if fake.path != "/repos/acme/payments/pulls/42/requested_reviewers" {
+				t.Errorf("path = %s", fake.path)
+			}
+			if fake.auth != "Bearer token-1" {
+				t.Errorf("Authorization = %q", fake.auth)
+			}
+			if !reflect.DeepEqual(fake.reviewers, []string{"octo-dev"}) {
+				t.Errorf("reviewers = %v", fake.reviewers)
+			}
+		})
+	}
+}
+
+func TestGitHubClientErrors(t *testing.T) {
+	pr := PullRequestRef{Owner: "acme", Repo: "payments", Number: 42}
+	tests := []struct {
+		status    int
+		permanent bool
+	}{
+		{http.StatusUnprocessableEntity, true},
+		{http.StatusNotFound, true},
+		{http.StatusForbidden, true},
+		{http.StatusRequestTimeout, false},
+		{http.StatusTooManyRequests, false},
+		{http.StatusInternalServerError, false},
+		{http.StatusBadGateway, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(http.StatusText(tt.status), func(t *testing.T) {
+			srv := httptest.NewServer(&fakeGitHub{status: tt.status, message: "Reviews may only be requested from collaborators"})
+			defer srv.Close()
+
+			err := NewGitHubClient(srv.URL, "", nil).RequestReviewers(context.Background(), pr, []string{"octo-dev"})
+			var se *StatusError
+			if !errors.As(err, &se) {
+				t.Fatalf("error = %v, want *StatusError", err)
+			}
+			if se.StatusCode != tt.status || se.Message != "Reviews may only be requested from collaborators" {
+				t.Errorf("StatusError = %+v", se)
+			}
+			if IsPermanent(err) != tt.permanent {
+				t.Errorf("IsPermanent = %v, want %v", IsPermanent(err), tt.permanent)
+			}
+		})
+	}
+}
+
+func TestGitHubClientNetworkErrorIsTemporary(t *testing.T) {
+	srv := httptest.NewServer(&fakeGitHub{status: http.StatusCreated})
+	srv.Close()
+
+	err := NewGitHubClient(srv.URL, "", nil).RequestReviewers(context.Background(),
+		PullRequestRef{Owner: "acme", Repo: "payments", Number: 42}, []string{"octo-dev"})
+	if err == nil {
+		t.Fatal("expected error from closed server")
+	}
+	if IsPermanent(err) {
+		t.Error("network error reported as permanent")
+	}
+}
+
+func TestPermanent(t *testing.T) {
+	base := errors.New("user u1 has no github login")
+	err := Permanent(base)
+	if !IsPermanent(err) {
+		t.Error("Permanent error is not permanent")
+	}
+	if !errors.Is(err, base) || err.Error() != base.Error() {
+		t.Errorf("Permanent changed the error: %v", err)
+	}
+	if IsPermanent(base) {
+		t.Error("plain error reported as permanent")
+	}
+}
+
+func TestParseGitHubID(t *testing.T) {
+	tests := []struct {
+		id   string
+		want PullRequestRef
+		ok   bool
+	}{
+		{"acme/payments#42", PullRequestRef{Owner: "acme", Repo: "payments", Number: 42}, true},
+		{"payments/billing-api!17", PullRequestRef{}, false},
+		{"pr-1001", PullRequestRef{}, false},
+		{"acme/payments#0", PullRequestRef{}, false},
+	}
+	for _, tt := range tests {
+		got, ok := ParseGitHubID(tt.id)
+		if ok != tt.ok || got != tt.want {
+			t.Errorf("ParseGitHubID(%q) = %+v, %v; want %+v, %v", tt.id, got, ok, tt.want, tt.ok)
+		}
+	}
+}
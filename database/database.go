@@ -67,14 +67,230 @@ func (db *DB) InitSchema() error {
 			FOREIGN KEY (reviewer_id) REFERENCES users(user_id) ON DELETE CASCADE
 		)`,
 
+		// Время назначения ревьювера и отправки напоминания
+		`ALTER TABLE pr_reviewers ADD COLUMN IF NOT EXISTS assigned_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP`,
+		`ALTER TABLE pr_reviewers ADD COLUMN IF NOT EXISTS reminded_at TIMESTAMP`,
+
+		// Приоритет и метки PR
+		`ALTER TABLE pull_requests ADD COLUMN IF NOT EXISTS priority VARCHAR(20) NOT NULL DEFAULT 'normal'`,
+		`CREATE TABLE IF NOT EXISTS pr_labels (
+			pull_request_id VARCHAR(255) NOT NULL,
+			label VARCHAR(255) NOT NULL,
+			PRIMARY KEY (pull_request_id, label),
+			FOREIGN KEY (pull_request_id) REFERENCES pull_requests(pull_request_id) ON DELETE CASCADE
+		)`,
+
+		// Размер PR и оценка трудоемкости ревью
+		`ALTER TABLE pull_requests ADD COLUMN IF NOT EXISTS additions INT NOT NULL DEFAULT 0`,
+		`ALTER TABLE pull_requests ADD COLUMN IF NOT EXISTS deletions INT NOT NULL DEFAULT 0`,
+		`ALTER TABLE pull_requests ADD COLUMN IF NOT EXISTS files_changed INT NOT NULL DEFAULT 0`,
+		`ALTER TABLE pull_requests ADD COLUMN IF NOT EXISTS review_effort INT NOT NULL DEFAULT 1`,
+
+		// Политика команды для крупных PR
+		`ALTER TABLE teams ADD COLUMN IF NOT EXISTS large_pr_effort_threshold INT NOT NULL DEFAULT 0`,
+		`ALTER TABLE teams ADD COLUMN IF NOT EXISTS large_pr_extra_reviewers INT NOT NULL DEFAULT 0`,
+
+		// Наставничество: обучающиеся ревьюверы и роли ревьюверов в PR
+		`ALTER TABLE users ADD COLUMN IF NOT EXISTS is_learning_reviewer BOOLEAN NOT NULL DEFAULT false`,
+		`ALTER TABLE users ADD COLUMN IF NOT EXISTS is_senior BOOLEAN NOT NULL DEFAULT false`,
+		`ALTER TABLE pr_reviewers ADD COLUMN IF NOT EXISTS role VARCHAR(20) NOT NULL DEFAULT 'required'`,
+
+		// Иерархия команд: отдел -> команда -> squad
+		`ALTER TABLE teams ADD COLUMN IF NOT EXISTS parent_team_name VARCHAR(255) REFERENCES teams(team_name) ON DELETE SET NULL`,
+
+		// Переименование команды каскадно обновляет ссылки на нее
+		`DO $$
+		BEGIN
+			IF EXISTS (SELECT 1 FROM pg_constraint WHERE conname = 'team_members_team_name_fkey' AND confupdtype <> 'c') THEN
+				ALTER TABLE team_members DROP CONSTRAINT team_members_team_name_fkey;
+				ALTER TABLE team_members ADD CONSTRAINT team_members_team_name_fkey
+					FOREIGN KEY (team_name) REFERENCES teams(team_name) ON DELETE CASCADE ON UPDATE CASCADE;
+			END IF;
+			IF EXISTS (SELECT 1 FROM pg_constraint WHERE conname = 'teams_parent_team_name_fkey' AND confupdtype <> 'c') THEN
+				ALTER TABLE teams DROP CONSTRAINT teams_parent_team_name_fkey;
+				ALTER TABLE teams ADD CONSTRAINT teams_parent_team_name_fkey
+					FOREIGN KEY (parent_team_name) REFERENCES teams(team_name) ON DELETE SET NULL ON UPDATE CASCADE;
+			END IF;
+		END $$`,
+
+		// Навыки пользователей
+		`CREATE TABLE IF NOT EXISTS user_skills (
+			user_id VARCHAR(255) NOT NULL,
+			skill VARCHAR(255) NOT NULL,
+			PRIMARY KEY (user_id, skill),
+			FOREIGN KEY (user_id) REFERENCES users(user_id) ON DELETE CASCADE
+		)`,
+
+		// Уволенные пользователи (в отличие от неактивных никогда не назначаются снова)
+		`ALTER TABLE users ADD COLUMN IF NOT EXISTS departed_at TIMESTAMP`,
+
+		// Вердикты ревьюверов
+		`ALTER TABLE pr_reviewers ADD COLUMN IF NOT EXISTS verdict VARCHAR(30)`,
+		`ALTER TABLE pr_reviewers ADD COLUMN IF NOT EXISTS reviewed_at TIMESTAMP`,
+
+		// История переназначений ревьюверов
+		`CREATE TABLE IF NOT EXISTS pr_reassignments (
+			id BIGSERIAL PRIMARY KEY,
+			pull_request_id VARCHAR(255) NOT NULL,
+			old_reviewer_id VARCHAR(255) NOT NULL,
+			new_reviewer_id VARCHAR(255) NOT NULL,
+			reassigned_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+			FOREIGN KEY (pull_request_id) REFERENCES pull_requests(pull_request_id) ON DELETE CASCADE,
+			FOREIGN KEY (old_reviewer_id) REFERENCES users(user_id) ON DELETE CASCADE,
+			FOREIGN KEY (new_reviewer_id) REFERENCES users(user_id) ON DELETE CASCADE
+		)`,
+		// Когда было сделано замененное назначение: учитывается в статистике прежнего ревьювера
+		`ALTER TABLE pr_reassignments ADD COLUMN IF NOT EXISTS old_assigned_at TIMESTAMP`,
+
+		// Закрытие неактивных PR по политике команды
+		`ALTER TABLE pull_requests ADD COLUMN IF NOT EXISTS closed_at TIMESTAMP`,
+		`ALTER TABLE teams ADD COLUMN IF NOT EXISTS auto_close_after_days INT NOT NULL DEFAULT 0`,
+
+		// Журнал событий PR (только добавление)
+		`CREATE TABLE IF NOT EXISTS pr_events (
+			id BIGSERIAL PRIMARY KEY,
+			pull_request_id VARCHAR(255) NOT NULL,
+			event_type VARCHAR(50) NOT NULL,
+			actor VARCHAR(255) NOT NULL,
+			payload JSONB NOT NULL DEFAULT '{}',
+			created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+			FOREIGN KEY (pull_request_id) REFERENCES pull_requests(pull_request_id)
+		)`,
+		`CREATE OR REPLACE FUNCTION pr_events_append_only() RETURNS trigger AS $$
+		BEGIN
+			RAISE EXCEPTION 'pr_events is append-only';
+		END
+		$$ LANGUAGE plpgsql`,
+		`DO $$
+		BEGIN
+			IF NOT EXISTS (SELECT 1 FROM pg_trigger WHERE tgname = 'pr_events_append_only') THEN
+				CREATE TRIGGER pr_events_append_only BEFORE UPDATE OR DELETE ON pr_events
+					FOR EACH ROW EXECUTE FUNCTION pr_events_append_only();
+			END IF;
+		END $$`,
+
+		// Журнал аудита изменений
+		`CREATE TABLE IF NOT EXISTS audit_log (
+			id BIGSERIAL PRIMARY KEY,
+			actor VARCHAR(255) NOT NULL,
+			endpoint VARCHAR(255) NOT NULL DEFAULT '',
+			request_id VARCHAR(255) NOT NULL DEFAULT '',
+			entity_type VARCHAR(50) NOT NULL,
+			entity_id VARCHAR(255) NOT NULL,
+			before_state JSONB,
+			after_state JSONB,
+			created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+		)`,
+
+		// Outbox: события для внешних систем, пишутся в одной транзакции с изменением
+		`CREATE TABLE IF NOT EXISTS outbox (
+			id BIGSERIAL PRIMARY KEY,
+			event_type VARCHAR(50) NOT NULL,
+			aggregate_type VARCHAR(50) NOT NULL,
+			aggregate_id VARCHAR(255) NOT NULL,
+			payload JSONB NOT NULL DEFAULT '{}',
+			created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+			attempts INT NOT NULL DEFAULT 0,
+			next_attempt_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+			last_error TEXT,
+			delivered_at TIMESTAMP
+		)`,
+
+		// Исходящие вебхуки и журнал их доставок
+		`CREATE TABLE IF NOT EXISTS webhooks (
+			id BIGSERIAL PRIMARY KEY,
+			url TEXT NOT NULL,
+			secret TEXT NOT NULL,
+			events TEXT[] NOT NULL DEFAULT '{}',
+			created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+		)`,
+		`CREATE TABLE IF NOT EXISTS webhook_deliveries (
+			id BIGSERIAL PRIMARY KEY,
+			webhook_id BIGINT NOT NULL,
+			event_id BIGINT NOT NULL,
+			event_type VARCHAR(50) NOT NULL,
+			payload JSONB NOT NULL,
+			status VARCHAR(20) NOT NULL DEFAULT 'pending',
+			attempts INT NOT NULL DEFAULT 0,
+			next_attempt_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+			last_status_code INT,
+			last_error TEXT,
+			created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+			delivered_at TIMESTAMP,
+			FOREIGN KEY (webhook_id) REFERENCES webhooks(id) ON DELETE CASCADE
+		)`,
+
+		// Логины пользователей во внешних системах (GitHub и т.п.)
+		`CREATE TABLE IF NOT EXISTS user_identities (
+			provider VARCHAR(50) NOT NULL,
+			login VARCHAR(255) NOT NULL,
+			user_id VARCHAR(255) NOT NULL,
+			PRIMARY KEY (provider, login),
+			FOREIGN KEY (user_id) REFERENCES users(user_id) ON DELETE CASCADE
+		)`,
+
+		// GitLab сообщает об авторе MR только числовой ID
+		`ALTER TABLE user_identities ADD COLUMN IF NOT EXISTS external_id VARCHAR(255) NOT NULL DEFAULT ''`,
+
+		// Время первого вердикта: reviewed_at перезаписывается каждым новым вердиктом
+		`ALTER TABLE pr_reviewers ADD COLUMN IF NOT EXISTS first_reviewed_at TIMESTAMP`,
+		`UPDATE pr_reviewers SET first_reviewed_at = reviewed_at WHERE first_reviewed_at IS NULL AND reviewed_at IS NOT NULL`,
+
+		// Очередь синхронизации ревьюверов с внешней системой
+		`CREATE TABLE IF NOT EXISTS codehost_sync (
+			id BIGSERIAL PRIMARY KEY,
+			pull_request_id VARCHAR(255) NOT NULL,
+			action VARCHAR(20) NOT NULL,
+			user_id VARCHAR(255) NOT NULL,
+			status VARCHAR(20) NOT NULL DEFAULT 'pending',
+			attempts INT NOT NULL DEFAULT 0,
+			next_attempt_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+			last_error TEXT,
+			created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+			finished_at TIMESTAMP
+		)`,
+
+		// Входящий вебхук чата команды (Slack/Mattermost)
+		`ALTER TABLE teams ADD COLUMN IF NOT EXISTS chat_webhook_url TEXT NOT NULL DEFAULT ''`,
+
+		// Очередь уведомлений о назначениях и merge; запись удаляется после отправки
+		`CREATE TABLE IF NOT EXISTS event_notifications (
+			id BIGSERIAL PRIMARY KEY,
+			message JSONB NOT NULL,
+			attempts INTEGER NOT NULL DEFAULT 0,
+			last_error TEXT,
+			next_attempt_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+			created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+		)`,
+
 		// Индексы для оптимизации
 		`CREATE INDEX IF NOT EXISTS idx_users_active ON users(is_active)`,
 		`CREATE INDEX IF NOT EXISTS idx_team_members_team ON team_members(team_name)`,
 		`CREATE INDEX IF NOT EXISTS idx_team_members_user ON team_members(user_id)`,
 		`CREATE INDEX IF NOT EXISTS idx_pr_author ON pull_requests(author_id)`,
 		`CREATE INDEX IF NOT EXISTS idx_pr_status ON pull_requests(status)`,
+		`CREATE INDEX IF NOT EXISTS idx_pr_created ON pull_requests(created_at DESC, pull_request_id DESC)`,
+		`CREATE INDEX IF NOT EXISTS idx_pr_merged ON pull_requests(merged_at)`,
 		`CREATE INDEX IF NOT EXISTS idx_pr_reviewers_pr ON pr_reviewers(pull_request_id)`,
 		`CREATE INDEX IF NOT EXISTS idx_pr_reviewers_reviewer ON pr_reviewers(reviewer_id)`,
+		`CREATE INDEX IF NOT EXISTS idx_pr_labels_label ON pr_labels(label)`,
+		`CREATE INDEX IF NOT EXISTS idx_teams_parent ON teams(parent_team_name)`,
+		`CREATE INDEX IF NOT EXISTS idx_pr_reassignments_pr ON pr_reassignments(pull_request_id)`,
+		`CREATE INDEX IF NOT EXISTS idx_pr_reassignments_time ON pr_reassignments(reassigned_at)`,
+		`CREATE INDEX IF NOT EXISTS idx_pr_reviewers_assigned ON pr_reviewers(assigned_at)`,
+		`CREATE INDEX IF NOT EXISTS idx_user_skills_skill ON user_skills(skill)`,
+		`CREATE INDEX IF NOT EXISTS idx_pr_events_pr ON pr_events(pull_request_id, id)`,
+		`CREATE INDEX IF NOT EXISTS idx_audit_log_actor ON audit_log(actor, id)`,
+		`CREATE INDEX IF NOT EXISTS idx_audit_log_entity ON audit_log(entity_type, entity_id, id)`,
+		`CREATE INDEX IF NOT EXISTS idx_audit_log_created ON audit_log(created_at)`,
+		`CREATE INDEX IF NOT EXISTS idx_outbox_pending ON outbox(aggregate_type, aggregate_id, id) WHERE delivered_at IS NULL`,
+		`CREATE INDEX IF NOT EXISTS idx_webhook_deliveries_pending ON webhook_deliveries(next_attempt_at) WHERE status = 'pending'`,
+		`CREATE INDEX IF NOT EXISTS idx_webhook_deliveries_webhook ON webhook_deliveries(webhook_id, id)`,
+		`CREATE INDEX IF NOT EXISTS idx_user_identities_user ON user_identities(user_id)`,
+		`CREATE INDEX IF NOT EXISTS idx_user_identities_external ON user_identities(provider, external_id) WHERE external_id <> ''`,
+		`CREATE INDEX IF NOT EXISTS idx_codehost_sync_pending ON codehost_sync(pull_request_id, id) WHERE status = 'pending'`,
+		`CREATE INDEX IF NOT EXISTS idx_event_notifications_next ON event_notifications(next_attempt_at)`,
+		`CREATE INDEX IF NOT EXISTS idx_users_username_prefix ON users(lower(username) text_pattern_ops)`,
 	}
 
 	for _, query := range queries {
@@ -0,0 +1,170 @@
+package handlers
+
+import (
+	"avito/models"
+	"embed"
+	"html/template"
+	"log"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+//go:embed templates/dashboard.html
+var templatesFS embed.FS
+
+var dashboardTemplate = template.Must(template.New("dashboard.html").Funcs(template.FuncMap{
+	"formatTime": func(t time.Time) string { return t.Format("2006-01-02 15:04") },
+}).ParseFS(templatesFS, "templates/dashboard.html"))
+
+// dashboardPage данные шаблона панели
+type dashboardPage struct {
+	Dashboard  *models.Dashboard
+	StaleAfter time.Duration
+	Actor      string // кто выполняет действия из форм панели, попадает в журнал аудита
+	Message    string
+	Error      string
+}
+
+// Dashboard отображает HTML-панель нагрузки ревьюверов
+func (h *Handlers) Dashboard(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		h.respondError(w, http.StatusMethodNotAllowed, "ERROR", "Method not allowed")
+		return
+	}
+
+	staleAfter, ok := h.parseDurationParam(w, r, "older_than", 7*24*time.Hour)
+	if !ok {
+		return
+	}
+
+	dashboard, err := h.svc(r).GetDashboard(staleAfter)
+	if err != nil {
+		log.Printf("Error building dashboard: %v", err)
+		status, _, msg := h.parseError(err)
+		http.Error(w, msg, status)
+		return
+	}
+
+	page := dashboardPage{
+		Dashboard:  dashboard,
+		StaleAfter: staleAfter,
+		Actor:      r.URL.Query().Get("actor"),
+		Message:    r.URL.Query().Get("message"),
+		Error:      r.URL.Query().Get("error"),
+	}
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	if err := dashboardTemplate.Execute(w, page); err != nil {
+		log.Printf("Error rendering dashboard: %v", err)
+	}
+}
+
+// DashboardSetUserActive переключает активность пользователя из формы панели
+func (h *Handlers) DashboardSetUserActive(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		h.respondError(w, http.StatusMethodNotAllowed, "ERROR", "Method not allowed")
+		return
+	}
+
+	actor, ok := h.dashboardActor(w, r)
+	if !ok {
+		return
+	}
+
+	userID := r.PostFormValue("user_id")
+	isActive, err := strconv.ParseBool(r.PostFormValue("is_active"))
+	if err != nil {
+		h.redirectDashboard(w, r, "", "is_active must be true or false")
+		return
+	}
+
+	user, err := h.svcAs(r, actor).SetUserActive(userID, isActive)
+	if err != nil {
+		log.Printf("Error setting user activity: %v", err)
+		_, _, msg := h.parseError(err)
+		h.redirectDashboard(w, r, "", msg)
+		return
+	}
+
+	state := "deactivated"
+	if user.IsActive {
+		state = "activated"
+	}
+	h.redirectDashboard(w, r, "User "+user.UserID+" "+state, "")
+}
+
+// DashboardReassign переназначает ревьювера из формы панели
+func (h *Handlers) DashboardReassign(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		h.respondError(w, http.StatusMethodNotAllowed, "ERROR", "Method not allowed")
+		return
+	}
+
+	actor, ok := h.dashboardActor(w, r)
+	if !ok {
+		return
+	}
+
+	pullRequestID := r.PostFormValue("pull_request_id")
+	oldUserID := r.PostFormValue("old_user_id")
+	_, newReviewerID, err := h.svcAs(r, actor).ReassignReviewer(pullRequestID, oldUserID)
+	if err != nil {
+		log.Printf("Error reassigning reviewer: %v", err)
+		_, _, msg := h.parseError(err)
+		h.redirectDashboard(w, r, "", msg)
+		return
+	}
+
+	h.redirectDashboard(w, r, "Reviewer "+oldUserID+" replaced by "+newReviewerID+" on "+pullRequestID, "")
+}
+
+// dashboardActor проверяет, что форма отправлена со страницы самого сервиса (защита от CSRF),
+// и возвращает исполнителя из поля actor; при ошибке ответ уже записан
+func (h *Handlers) dashboardActor(w http.ResponseWriter, r *http.Request) (string, bool) {
+	if !sameOrigin(r) {
+		http.Error(w, "cross-origin form submission is not allowed", http.StatusForbidden)
+		return "", false
+	}
+	actor := strings.TrimSpace(r.PostFormValue("actor"))
+	if actor == "" {
+		h.redirectDashboard(w, r, "", "actor is required: enter your name at the top of the page")
+		return "", false
+	}
+	return actor, true
+}
+
+// sameOrigin сообщает, пришел ли запрос со страницы того же хоста по заголовку Origin,
+// а если браузер его не прислал - по Referer. Запрос без обоих заголовков отклоняется
+func sameOrigin(r *http.Request) bool {
+	source := r.Header.Get("Origin")
+	if source == "" {
+		source = r.Header.Get("Referer")
+	}
+	if source == "" {
+		return false
+	}
+	u, err := url.Parse(source)
+	return err == nil && u.Host != "" && u.Host == r.Host
+}
+
+// redirectDashboard возвращает на панель с сообщением о результате (Post/Redirect/Get),
+// сохраняя исполнителя из формы
+func (h *Handlers) redirectDashboard(w http.ResponseWriter, r *http.Request, message, errMsg string) {
+	q := url.Values{}
+	if actor := strings.TrimSpace(r.PostFormValue("actor")); actor != "" {
+		q.Set("actor", actor)
+	}
+	if message != "" {
+		q.Set("message", message)
+	}
+	if errMsg != "" {
+		q.Set("error", errMsg)
+	}
+	target := "/dashboard"
+	if len(q) > 0 {
+		target += "?" + q.Encode()
+	}
+	http.Redirect(w, r, target, http.StatusSeeOther)
+}
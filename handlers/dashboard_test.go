@@ -0,0 +1,90 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+// postDashboard отправляет форму панели; сервис не задан, поэтому до него доходить не должно
+func postDashboard(t *testing.T, path string, form url.Values, header map[string]string) *httptest.ResponseRecorder {
+	t.Helper()
+	req := httptest.NewRequest(http.MethodPost, "http://reviews.example.com"+path, strings.NewReader(form.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	for k, v := range header {
+		req.Header.Set(k, v)
+	}
+	rec := httptest.NewRecorder()
+	h := NewHandlers(nil)
+	mux := http.NewServeMux()
+	mux.HandleFunc("/dashboard/setIsActive", h.DashboardSetUserActive)
+	mux.HandleFunc("/dashboard/reassign", h.DashboardReassign)
+	mux.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestDashboardRejectsCrossOriginForms(t *testing.T) {
+	form := url.Values{"actor": {"lead"}, "user_id": {"u1"}, "is_active": {"false"},
+		"pull_request_id": {"pr-1001"}, "old_user_id": {"u2"}}
+	tests := []struct {
+		name   string
+		header map[string]string
+	}{
+		{"foreign origin", map[string]string{"Origin": "https://evil.example.org"}},
+		{"foreign referer", map[string]string{"Referer": "https://evil.example.org/page"}},
+		{"opaque origin", map[string]string{"Origin": "null"}},
+		{"no origin or referer", nil},
+	}
+	for _, path := range []string{"/dashboard/setIsActive", "/dashboard/reassign"} {
+		for _, tt := range tests {
+			t.Run(path+" "+tt.name, func(t *testing.T) {
+				rec := postDashboard(t, path, form, tt.header)
+				if rec.Code != http.StatusForbidden {
+					t.Errorf("status = %d, want %d", rec.Code, http.StatusForbidden)
+				}
+			})
+		}
+	}
+}
+
+func TestDashboardRequiresActor(t *testing.T) {
+	form := url.Values{"actor": {"  "}, "user_id": {"u1"}, "is_active": {"false"}}
+	rec := postDashboard(t, "/dashboard/setIsActive", form, map[string]string{"Origin": "http://reviews.example.com"})
+	if rec.Code != http.StatusSeeOther {
+		t.Fatalf("status = %d, want %d", rec.Code, http.StatusSeeOther)
+	}
+	location, err := url.Parse(rec.Header().Get("Location"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if location.Path != "/dashboard" || !strings.HasPrefix(location.Query().Get("error"), "actor is required") {
+		t.Errorf("redirect to %s, want dashboard with actor error", location)
+	}
+}
+
+func TestSameOrigin(t *testing.T) {
+	tests := []struct {
+		origin, referer string
+		want            bool
+	}{
+		{"http://reviews.example.com", "", true},
+		{"", "http://reviews.example.com/dashboard?actor=lead", true},
+		{"http://reviews.example.com:8080", "", false},
+		{"https://evil.example.org", "http://reviews.example.com/dashboard", false},
+		{"", "", false},
+	}
+	for _, tt := range tests {
+		req := httptest.NewRequest(http.MethodPost, "http://reviews.example.com/dashboard/reassign", nil)
+		if tt.origin != "" {
+			req.Header.Set("Origin", tt.origin)
+		}
+		if tt.referer != "" {
+			req.Header.Set("Referer", tt.referer)
+		}
+		if got := sameOrigin(req); got != tt.want {
+			t.Errorf("sameOrigin(Origin %q, Referer %q) = %v, want %v", tt.origin, tt.referer, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,236 @@
+package handlers
+
+import (
+	"avito/models"
+	"encoding/csv"
+	"encoding/json"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Форматы выгрузки
+const (
+	exportCSV    = "csv"
+	exportNDJSON = "ndjson"
+)
+
+// exportFlushEvery через сколько строк буфер отправляется клиенту
+const exportFlushEvery = 500
+
+// exportWriter пишет строки выгрузки в CSV или NDJSON прямо в ответ.
+// Заголовки ответа отправляются при первой строке, чтобы до нее можно было ответить ошибкой
+type exportWriter struct {
+	w       http.ResponseWriter
+	name    string
+	format  string
+	columns []string
+	csv     *csv.Writer
+	json    *json.Encoder
+	rows    int
+	started bool
+}
+
+// newExportWriter выбирает формат по параметру format или заголовку Accept (по умолчанию CSV);
+// при неизвестном формате отвечает 400 и возвращает false
+func (h *Handlers) newExportWriter(w http.ResponseWriter, r *http.Request, name string) (*exportWriter, bool) {
+	format := r.URL.Query().Get("format")
+	if format == "" {
+		accept := r.Header.Get("Accept")
+		if strings.Contains(accept, "application/x-ndjson") || strings.Contains(accept, "application/jsonl") {
+			format = exportNDJSON
+		} else {
+			format = exportCSV
+		}
+	}
+	if format != exportCSV && format != exportNDJSON {
+		h.respondError(w, http.StatusBadRequest, "ERROR", "format must be csv or ndjson")
+		return nil, false
+	}
+	return &exportWriter{w: w, name: name, format: format}, true
+}
+
+func (e *exportWriter) start() error {
+	if e.started {
+		return nil
+	}
+	e.started = true
+
+	if e.format == exportCSV {
+		e.w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+		e.w.Header().Set("Content-Disposition", `attachment; filename="`+e.name+`.csv"`)
+		e.csv = csv.NewWriter(e.w)
+		return e.csv.Write(e.columns)
+	}
+	e.w.Header().Set("Content-Type", "application/x-ndjson")
+	e.w.Header().Set("Content-Disposition", `attachment; filename="`+e.name+`.ndjson"`)
+	e.json = json.NewEncoder(e.w)
+	return nil
+}
+
+// write пишет строку: v - для NDJSON, record - колонки CSV в порядке columns
+func (e *exportWriter) write(v interface{}, record []string) error {
+	if err := e.start(); err != nil {
+		return err
+	}
+
+	var err error
+	if e.format == exportCSV {
+		err = e.csv.Write(record)
+	} else {
+		err = e.json.Encode(v)
+	}
+	if err != nil {
+		return err
+	}
+
+	e.rows++
+	if e.rows%exportFlushEvery == 0 {
+		return e.flush()
+	}
+	return nil
+}
+
+func (e *exportWriter) flush() error {
+	if e.csv != nil {
+		e.csv.Flush()
+		if err := e.csv.Error(); err != nil {
+			return err
+		}
+	}
+	if f, ok := e.w.(http.Flusher); ok {
+		f.Flush()
+	}
+	return nil
+}
+
+// finishExport завершает выгрузку. Если ни одной строки еще не записано, ошибка
+// возвращается обычным JSON-ответом; иначе поток обрывается и ошибка только логируется
+func (h *Handlers) finishExport(e *exportWriter, err error) {
+	if err != nil {
+		log.Printf("Error exporting %s after %d rows: %v", e.name, e.rows, err)
+		if !e.started {
+			status, code, msg := h.parseError(err)
+			h.respondError(e.w, status, code, msg)
+		}
+		return
+	}
+
+	if err := e.start(); err != nil {
+		log.Printf("Error exporting %s: %v", e.name, err)
+		return
+	}
+	if err := e.flush(); err != nil {
+		log.Printf("Error exporting %s: %v", e.name, err)
+	}
+}
+
+func formatExportTime(t *time.Time) string {
+	if t == nil {
+		return ""
+	}
+	return t.Format(time.RFC3339)
+}
+
+// ExportPRs выгружает PR по фильтрам /pullRequest/list
+func (h *Handlers) ExportPRs(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		h.respondError(w, http.StatusMethodNotAllowed, "ERROR", "Method not allowed")
+		return
+	}
+
+	filter, ok := h.parsePRFilter(w, r)
+	if !ok {
+		return
+	}
+	e, ok := h.newExportWriter(w, r, "prs")
+	if !ok {
+		return
+	}
+
+	e.columns = []string{"pull_request_id", "pull_request_name", "author_id", "status", "priority", "labels",
+		"assigned_reviewers", "additions", "deletions", "files_changed", "review_effort", "created_at", "merged_at", "closed_at"}
+	err := h.svc(r).ExportPRs(filter, func(pr *models.PRExportRow) error {
+		return e.write(pr, []string{pr.PullRequestID, pr.PullRequestName, pr.AuthorID, pr.Status, pr.Priority,
+			strings.Join(pr.Labels, ";"), strings.Join(pr.AssignedReviewers, ";"),
+			strconv.Itoa(pr.Additions), strconv.Itoa(pr.Deletions), strconv.Itoa(pr.FilesChanged), strconv.Itoa(pr.ReviewEffort),
+			formatExportTime(&pr.CreatedAt), formatExportTime(pr.MergedAt), formatExportTime(pr.ClosedAt)})
+	})
+	h.finishExport(e, err)
+}
+
+// ExportAssignments выгружает назначения ревьюверов
+func (h *Handlers) ExportAssignments(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		h.respondError(w, http.StatusMethodNotAllowed, "ERROR", "Method not allowed")
+		return
+	}
+
+	filter, ok := h.parseStatsFilter(w, r)
+	if !ok {
+		return
+	}
+	e, ok := h.newExportWriter(w, r, "assignments")
+	if !ok {
+		return
+	}
+
+	e.columns = []string{"pull_request_id", "author_id", "pr_status", "reviewer_id", "role", "assigned_at", "verdict", "reviewed_at"}
+	err := h.svc(r).ExportAssignments(filter, func(a *models.AssignmentExportRow) error {
+		return e.write(a, []string{a.PullRequestID, a.AuthorID, a.PRStatus, a.ReviewerID, a.Role,
+			formatExportTime(&a.AssignedAt), a.Verdict, formatExportTime(a.ReviewedAt)})
+	})
+	h.finishExport(e, err)
+}
+
+// ExportStats выгружает одну из таблиц статистики /stats: kind=users (по умолчанию), pull_requests или teams
+func (h *Handlers) ExportStats(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		h.respondError(w, http.StatusMethodNotAllowed, "ERROR", "Method not allowed")
+		return
+	}
+
+	filter, ok := h.parseStatsFilter(w, r)
+	if !ok {
+		return
+	}
+
+	kind := r.URL.Query().Get("kind")
+	if kind == "" {
+		kind = "users"
+	}
+	if kind != "users" && kind != "pull_requests" && kind != "teams" {
+		h.respondError(w, http.StatusBadRequest, "ERROR", "kind must be users, pull_requests or teams")
+		return
+	}
+
+	e, ok := h.newExportWriter(w, r, "stats_"+kind)
+	if !ok {
+		return
+	}
+
+	var err error
+	switch kind {
+	case "users":
+		e.columns = []string{"user_id", "username", "assigned", "open_reviews", "reassigned_from", "reassigned_to"}
+		err = h.svc(r).ExportUserStats(filter, func(st models.UserAssignmentStats) error {
+			return e.write(st, []string{st.UserID, st.Username, strconv.Itoa(st.Assigned), strconv.Itoa(st.OpenReviews),
+				strconv.Itoa(st.ReassignedFrom), strconv.Itoa(st.ReassignedTo)})
+		})
+	case "pull_requests":
+		e.columns = []string{"pull_request_id", "author_id", "status", "reviewers", "reassignments"}
+		err = h.svc(r).ExportPRStats(filter, func(st models.PRAssignmentStats) error {
+			return e.write(st, []string{st.PullRequestID, st.AuthorID, st.Status,
+				strconv.Itoa(st.Reviewers), strconv.Itoa(st.Reassignments)})
+		})
+	case "teams":
+		e.columns = []string{"team_name", "prs_opened", "prs_merged", "reassignments"}
+		err = h.svc(r).ExportTeamStats(filter, func(st models.TeamPRStats) error {
+			return e.write(st, []string{st.TeamName, strconv.Itoa(st.PRsOpened), strconv.Itoa(st.PRsMerged),
+				strconv.Itoa(st.Reassignments)})
+		})
+	}
+	h.finishExport(e, err)
+}
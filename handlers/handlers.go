@@ -6,17 +6,36 @@ import (
 	"encoding/json"
 	"log"
 	"net/http"
+	"strconv"
 	"strings"
+	"time"
 )
 
 type Handlers struct {
-	service *service.Service
+	service      *service.Service
+	githubSecret string
+	gitlabToken  string
 }
 
 func NewHandlers(svc *service.Service) *Handlers {
 	return &Handlers{service: svc}
 }
 
+// svc возвращает сервис, действующий от имени исполнителя из заголовка X-Actor
+// в рамках текущего запроса
+func (h *Handlers) svc(r *http.Request) *service.Service {
+	return h.svcAs(r, r.Header.Get("X-Actor"))
+}
+
+// svcAs как svc, но с явно заданным исполнителем (для вызовов из внешних систем)
+func (h *Handlers) svcAs(r *http.Request, actor string) *service.Service {
+	return h.service.WithCaller(service.Caller{
+		Actor:     actor,
+		RequestID: requestIDFrom(r),
+		Endpoint:  r.Method + " " + r.URL.Path,
+	})
+}
+
 func (h *Handlers) respondJSON(w http.ResponseWriter, status int, data interface{}) {
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(status)
@@ -50,10 +69,20 @@ func (h *Handlers) parseError(err error) (int, string, string) {
 			return http.StatusConflict, "PR_EXISTS", message
 		case "PR_MERGED":
 			return http.StatusConflict, "PR_MERGED", message
+		case "PR_CLOSED":
+			return http.StatusConflict, "PR_CLOSED", message
 		case "NOT_ASSIGNED":
 			return http.StatusConflict, "NOT_ASSIGNED", message
 		case "NO_CANDIDATE":
 			return http.StatusConflict, "NO_CANDIDATE", message
+		case "TEAM_HAS_OPEN_REVIEWS":
+			return http.StatusConflict, "TEAM_HAS_OPEN_REVIEWS", message
+		case "USER_DEPARTED":
+			return http.StatusConflict, "USER_DEPARTED", message
+		case "TEAM_CYCLE":
+			return http.StatusConflict, "TEAM_CYCLE", message
+		case "UNKNOWN_USER":
+			return http.StatusUnprocessableEntity, "UNKNOWN_USER", message
 		case "NOT_FOUND":
 			return http.StatusNotFound, "NOT_FOUND", message
 		}
@@ -63,6 +92,55 @@ func (h *Handlers) parseError(err error) (int, string, string) {
 	return http.StatusBadRequest, "ERROR", errStr
 }
 
+// parseLimit читает параметр limit; при ошибке отвечает 400 и возвращает false
+func (h *Handlers) parseLimit(w http.ResponseWriter, r *http.Request) (int, bool) {
+	v := r.URL.Query().Get("limit")
+	if v == "" {
+		return 0, true
+	}
+	limit, err := strconv.Atoi(v)
+	if err != nil || limit < 0 {
+		h.respondError(w, http.StatusBadRequest, "ERROR", "limit must be a non-negative integer")
+		return 0, false
+	}
+	return limit, true
+}
+
+// parseTimeParam читает параметр-время в формате RFC3339 или YYYY-MM-DD;
+// при ошибке отвечает 400 и возвращает false
+func (h *Handlers) parseTimeParam(w http.ResponseWriter, r *http.Request, name string) (*time.Time, bool) {
+	v := r.URL.Query().Get(name)
+	if v == "" {
+		return nil, true
+	}
+	if t, err := time.Parse(time.RFC3339, v); err == nil {
+		return &t, true
+	}
+	if t, err := time.Parse("2006-01-02", v); err == nil {
+		return &t, true
+	}
+	h.respondError(w, http.StatusBadRequest, "ERROR", name+" must be RFC3339 time or YYYY-MM-DD date")
+	return nil, false
+}
+
+// parseDurationParam читает длительность в формате time.ParseDuration или в днях ("7d");
+// при ошибке отвечает 400 и возвращает false
+func (h *Handlers) parseDurationParam(w http.ResponseWriter, r *http.Request, name string, def time.Duration) (time.Duration, bool) {
+	v := r.URL.Query().Get(name)
+	if v == "" {
+		return def, true
+	}
+	if days, ok := strings.CutSuffix(v, "d"); ok {
+		if n, err := strconv.Atoi(days); err == nil && n > 0 {
+			return time.Duration(n) * 24 * time.Hour, true
+		}
+	} else if d, err := time.ParseDuration(v); err == nil && d > 0 {
+		return d, true
+	}
+	h.respondError(w, http.StatusBadRequest, "ERROR", name+" must be a positive duration (e.g. 72h or 7d)")
+	return 0, false
+}
+
 // AddTeam создает команду с участниками
 func (h *Handlers) AddTeam(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodPost {
@@ -77,7 +155,7 @@ func (h *Handlers) AddTeam(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	team, err := h.service.CreateTeam(&req)
+	team, err := h.svc(r).CreateTeam(&req)
 	if err != nil {
 		log.Printf("Error creating team: %v", err)
 		status, code, msg := h.parseError(err)
@@ -101,7 +179,7 @@ func (h *Handlers) GetTeam(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	team, err := h.service.GetTeam(teamName)
+	team, err := h.svc(r).GetTeam(teamName)
 	if err != nil {
 		log.Printf("Error getting team: %v", err)
 		status, code, msg := h.parseError(err)
@@ -112,6 +190,249 @@ func (h *Handlers) GetTeam(w http.ResponseWriter, r *http.Request) {
 	h.respondJSON(w, http.StatusOK, *team)
 }
 
+// SetTeamParent переносит команду в иерархии
+func (h *Handlers) SetTeamParent(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		h.respondError(w, http.StatusMethodNotAllowed, "ERROR", "Method not allowed")
+		return
+	}
+
+	var req models.SetTeamParentRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		log.Printf("Error decoding request body: %v", err)
+		h.respondError(w, http.StatusBadRequest, "ERROR", "Invalid request body")
+		return
+	}
+
+	team, err := h.svc(r).SetTeamParent(&req)
+	if err != nil {
+		log.Printf("Error setting parent team: %v", err)
+		status, code, msg := h.parseError(err)
+		h.respondError(w, status, code, msg)
+		return
+	}
+
+	h.respondJSON(w, http.StatusOK, models.TeamResponse{Team: *team})
+}
+
+// GetTeamTree возвращает дерево команд (целиком или поддерево team_name)
+func (h *Handlers) GetTeamTree(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		h.respondError(w, http.StatusMethodNotAllowed, "ERROR", "Method not allowed")
+		return
+	}
+
+	teams, err := h.svc(r).GetTeamTree(r.URL.Query().Get("team_name"))
+	if err != nil {
+		log.Printf("Error getting team tree: %v", err)
+		status, code, msg := h.parseError(err)
+		h.respondError(w, status, code, msg)
+		return
+	}
+
+	h.respondJSON(w, http.StatusOK, models.TeamTreeResponse{Teams: teams})
+}
+
+// SetTeamPolicy изменяет политику команды
+func (h *Handlers) SetTeamPolicy(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		h.respondError(w, http.StatusMethodNotAllowed, "ERROR", "Method not allowed")
+		return
+	}
+
+	var req models.SetTeamPolicyRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		log.Printf("Error decoding request body: %v", err)
+		h.respondError(w, http.StatusBadRequest, "ERROR", "Invalid request body")
+		return
+	}
+
+	team, err := h.svc(r).SetTeamPolicy(&req)
+	if err != nil {
+		log.Printf("Error setting team policy: %v", err)
+		status, code, msg := h.parseError(err)
+		h.respondError(w, status, code, msg)
+		return
+	}
+
+	h.respondJSON(w, http.StatusOK, models.TeamResponse{Team: *team})
+}
+
+// SetTeamChatWebhook задает входящий вебхук чата команды
+func (h *Handlers) SetTeamChatWebhook(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		h.respondError(w, http.StatusMethodNotAllowed, "ERROR", "Method not allowed")
+		return
+	}
+
+	var req models.SetTeamChatWebhookRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		log.Printf("Error decoding request body: %v", err)
+		h.respondError(w, http.StatusBadRequest, "ERROR", "Invalid request body")
+		return
+	}
+
+	team, err := h.svc(r).SetTeamChatWebhook(&req)
+	if err != nil {
+		log.Printf("Error setting team chat webhook: %v", err)
+		status, code, msg := h.parseError(err)
+		h.respondError(w, status, code, msg)
+		return
+	}
+
+	h.respondJSON(w, http.StatusOK, models.TeamResponse{Team: *team})
+}
+
+// UpsertTeam приводит команду к переданному составу
+func (h *Handlers) UpsertTeam(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPut {
+		h.respondError(w, http.StatusMethodNotAllowed, "ERROR", "Method not allowed")
+		return
+	}
+
+	var req models.UpsertTeamRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		log.Printf("Error decoding request body: %v", err)
+		h.respondError(w, http.StatusBadRequest, "ERROR", "Invalid request body")
+		return
+	}
+
+	resp, err := h.svc(r).UpsertTeam(&req)
+	if err != nil {
+		log.Printf("Error upserting team: %v", err)
+		status, code, msg := h.parseError(err)
+		h.respondError(w, status, code, msg)
+		return
+	}
+
+	h.respondJSON(w, http.StatusOK, *resp)
+}
+
+// RenameTeam переименовывает команду
+func (h *Handlers) RenameTeam(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		h.respondError(w, http.StatusMethodNotAllowed, "ERROR", "Method not allowed")
+		return
+	}
+
+	var req models.RenameTeamRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		log.Printf("Error decoding request body: %v", err)
+		h.respondError(w, http.StatusBadRequest, "ERROR", "Invalid request body")
+		return
+	}
+
+	team, err := h.svc(r).RenameTeam(&req)
+	if err != nil {
+		log.Printf("Error renaming team: %v", err)
+		status, code, msg := h.parseError(err)
+		h.respondError(w, status, code, msg)
+		return
+	}
+
+	h.respondJSON(w, http.StatusOK, models.TeamResponse{Team: *team})
+}
+
+// DeleteTeam удаляет команду
+func (h *Handlers) DeleteTeam(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		h.respondError(w, http.StatusMethodNotAllowed, "ERROR", "Method not allowed")
+		return
+	}
+
+	var req models.DeleteTeamRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		log.Printf("Error decoding request body: %v", err)
+		h.respondError(w, http.StatusBadRequest, "ERROR", "Invalid request body")
+		return
+	}
+
+	resp, err := h.svc(r).DeleteTeam(&req)
+	if err != nil {
+		log.Printf("Error deleting team: %v", err)
+		status, code, msg := h.parseError(err)
+		h.respondError(w, status, code, msg)
+		return
+	}
+
+	h.respondJSON(w, http.StatusOK, *resp)
+}
+
+// AddTeamMember добавляет участника в команду
+func (h *Handlers) AddTeamMember(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		h.respondError(w, http.StatusMethodNotAllowed, "ERROR", "Method not allowed")
+		return
+	}
+
+	var req models.AddTeamMemberRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		log.Printf("Error decoding request body: %v", err)
+		h.respondError(w, http.StatusBadRequest, "ERROR", "Invalid request body")
+		return
+	}
+
+	team, err := h.svc(r).AddTeamMember(&req)
+	if err != nil {
+		log.Printf("Error adding team member: %v", err)
+		status, code, msg := h.parseError(err)
+		h.respondError(w, status, code, msg)
+		return
+	}
+
+	h.respondJSON(w, http.StatusOK, models.TeamResponse{Team: *team})
+}
+
+// RemoveTeamMember удаляет участника из команды
+func (h *Handlers) RemoveTeamMember(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		h.respondError(w, http.StatusMethodNotAllowed, "ERROR", "Method not allowed")
+		return
+	}
+
+	var req models.RemoveTeamMemberRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		log.Printf("Error decoding request body: %v", err)
+		h.respondError(w, http.StatusBadRequest, "ERROR", "Invalid request body")
+		return
+	}
+
+	resp, err := h.svc(r).RemoveTeamMember(&req)
+	if err != nil {
+		log.Printf("Error removing team member: %v", err)
+		status, code, msg := h.parseError(err)
+		h.respondError(w, status, code, msg)
+		return
+	}
+
+	h.respondJSON(w, http.StatusOK, *resp)
+}
+
+// MoveTeamMember переводит участника в другую команду
+func (h *Handlers) MoveTeamMember(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		h.respondError(w, http.StatusMethodNotAllowed, "ERROR", "Method not allowed")
+		return
+	}
+
+	var req models.MoveTeamMemberRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		log.Printf("Error decoding request body: %v", err)
+		h.respondError(w, http.StatusBadRequest, "ERROR", "Invalid request body")
+		return
+	}
+
+	resp, err := h.svc(r).MoveTeamMember(&req)
+	if err != nil {
+		log.Printf("Error moving team member: %v", err)
+		status, code, msg := h.parseError(err)
+		h.respondError(w, status, code, msg)
+		return
+	}
+
+	h.respondJSON(w, http.StatusOK, *resp)
+}
+
 // SetUserActive устанавливает флаг активности пользователя
 func (h *Handlers) SetUserActive(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodPost {
@@ -126,7 +447,7 @@ func (h *Handlers) SetUserActive(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	user, err := h.service.SetUserActive(req.UserID, req.IsActive)
+	user, err := h.svc(r).SetUserActive(req.UserID, req.IsActive)
 	if err != nil {
 		log.Printf("Error setting user activity: %v", err)
 		status, code, msg := h.parseError(err)
@@ -137,6 +458,143 @@ func (h *Handlers) SetUserActive(w http.ResponseWriter, r *http.Request) {
 	h.respondJSON(w, http.StatusOK, models.UserResponse{User: *user})
 }
 
+// SetReviewerProfile изменяет флаги наставничества пользователя
+func (h *Handlers) SetReviewerProfile(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		h.respondError(w, http.StatusMethodNotAllowed, "ERROR", "Method not allowed")
+		return
+	}
+
+	var req models.SetReviewerProfileRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		log.Printf("Error decoding request body: %v", err)
+		h.respondError(w, http.StatusBadRequest, "ERROR", "Invalid request body")
+		return
+	}
+
+	user, err := h.svc(r).SetReviewerProfile(&req)
+	if err != nil {
+		log.Printf("Error setting reviewer profile: %v", err)
+		status, code, msg := h.parseError(err)
+		h.respondError(w, status, code, msg)
+		return
+	}
+
+	h.respondJSON(w, http.StatusOK, models.UserResponse{User: *user})
+}
+
+// GetUser возвращает пользователя со всеми командами и нагрузкой
+func (h *Handlers) GetUser(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		h.respondError(w, http.StatusMethodNotAllowed, "ERROR", "Method not allowed")
+		return
+	}
+
+	userID := r.URL.Query().Get("user_id")
+	if userID == "" {
+		h.respondError(w, http.StatusBadRequest, "ERROR", "user_id parameter is required")
+		return
+	}
+
+	user, err := h.svc(r).GetUser(userID)
+	if err != nil {
+		log.Printf("Error getting user: %v", err)
+		status, code, msg := h.parseError(err)
+		h.respondError(w, status, code, msg)
+		return
+	}
+
+	h.respondJSON(w, http.StatusOK, models.UserResponse{User: *user})
+}
+
+// ListUsers возвращает страницу пользователей с фильтрами
+func (h *Handlers) ListUsers(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		h.respondError(w, http.StatusMethodNotAllowed, "ERROR", "Method not allowed")
+		return
+	}
+
+	q := r.URL.Query()
+	filter := models.UserFilter{
+		TeamName:       q.Get("team"),
+		Skill:          q.Get("skill"),
+		UsernamePrefix: q.Get("q"),
+	}
+	if v := q.Get("active"); v != "" {
+		active, err := strconv.ParseBool(v)
+		if err != nil {
+			h.respondError(w, http.StatusBadRequest, "ERROR", "active must be true or false")
+			return
+		}
+		filter.IsActive = &active
+	}
+	limit, ok := h.parseLimit(w, r)
+	if !ok {
+		return
+	}
+	filter.Limit = limit
+
+	resp, err := h.svc(r).ListUsers(filter, q.Get("cursor"))
+	if err != nil {
+		log.Printf("Error listing users: %v", err)
+		status, code, msg := h.parseError(err)
+		h.respondError(w, status, code, msg)
+		return
+	}
+
+	h.respondJSON(w, http.StatusOK, *resp)
+}
+
+// SetUserSkills заменяет список навыков пользователя
+func (h *Handlers) SetUserSkills(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		h.respondError(w, http.StatusMethodNotAllowed, "ERROR", "Method not allowed")
+		return
+	}
+
+	var req models.SetUserSkillsRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		log.Printf("Error decoding request body: %v", err)
+		h.respondError(w, http.StatusBadRequest, "ERROR", "Invalid request body")
+		return
+	}
+
+	user, err := h.svc(r).SetUserSkills(&req)
+	if err != nil {
+		log.Printf("Error setting user skills: %v", err)
+		status, code, msg := h.parseError(err)
+		h.respondError(w, status, code, msg)
+		return
+	}
+
+	h.respondJSON(w, http.StatusOK, models.UserResponse{User: *user})
+}
+
+// OffboardUser увольняет пользователя с переназначением его ревью
+func (h *Handlers) OffboardUser(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		h.respondError(w, http.StatusMethodNotAllowed, "ERROR", "Method not allowed")
+		return
+	}
+
+	var req models.OffboardUserRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		log.Printf("Error decoding request body: %v", err)
+		h.respondError(w, http.StatusBadRequest, "ERROR", "Invalid request body")
+		return
+	}
+
+	resp, err := h.svc(r).OffboardUser(&req)
+	if err != nil {
+		log.Printf("Error offboarding user: %v", err)
+		status, code, msg := h.parseError(err)
+		h.respondError(w, status, code, msg)
+		return
+	}
+
+	h.respondJSON(w, http.StatusOK, *resp)
+}
+
 // CreatePR создает новый PR
 func (h *Handlers) CreatePR(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodPost {
@@ -151,7 +609,7 @@ func (h *Handlers) CreatePR(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	pr, err := h.service.CreatePR(&req)
+	pr, err := h.svc(r).CreatePR(&req)
 	if err != nil {
 		log.Printf("Error creating PR: %v", err)
 		status, code, msg := h.parseError(err)
@@ -162,6 +620,80 @@ func (h *Handlers) CreatePR(w http.ResponseWriter, r *http.Request) {
 	h.respondJSON(w, http.StatusCreated, models.PRResponse{PR: *pr})
 }
 
+// GetPR возвращает PR по идентификатору
+func (h *Handlers) GetPR(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		h.respondError(w, http.StatusMethodNotAllowed, "ERROR", "Method not allowed")
+		return
+	}
+
+	pullRequestID := r.URL.Query().Get("pull_request_id")
+	if pullRequestID == "" {
+		h.respondError(w, http.StatusBadRequest, "ERROR", "pull_request_id parameter is required")
+		return
+	}
+
+	pr, err := h.svc(r).GetPR(pullRequestID)
+	if err != nil {
+		log.Printf("Error getting PR: %v", err)
+		status, code, msg := h.parseError(err)
+		h.respondError(w, status, code, msg)
+		return
+	}
+
+	h.respondJSON(w, http.StatusOK, models.PRResponse{PR: *pr})
+}
+
+// GetPRTimeline возвращает историю событий PR
+func (h *Handlers) GetPRTimeline(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		h.respondError(w, http.StatusMethodNotAllowed, "ERROR", "Method not allowed")
+		return
+	}
+
+	pullRequestID := r.URL.Query().Get("pull_request_id")
+	if pullRequestID == "" {
+		h.respondError(w, http.StatusBadRequest, "ERROR", "pull_request_id parameter is required")
+		return
+	}
+
+	resp, err := h.svc(r).GetPRTimeline(pullRequestID)
+	if err != nil {
+		log.Printf("Error getting PR timeline: %v", err)
+		status, code, msg := h.parseError(err)
+		h.respondError(w, status, code, msg)
+		return
+	}
+
+	h.respondJSON(w, http.StatusOK, *resp)
+}
+
+// ListPRs возвращает страницу PR с фильтрами
+func (h *Handlers) ListPRs(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		h.respondError(w, http.StatusMethodNotAllowed, "ERROR", "Method not allowed")
+		return
+	}
+
+	filter, ok := h.parsePRFilter(w, r)
+	if !ok {
+		return
+	}
+	if filter.Limit, ok = h.parseLimit(w, r); !ok {
+		return
+	}
+
+	resp, err := h.svc(r).ListPRs(filter, r.URL.Query().Get("cursor"))
+	if err != nil {
+		log.Printf("Error listing PRs: %v", err)
+		status, code, msg := h.parseError(err)
+		h.respondError(w, status, code, msg)
+		return
+	}
+
+	h.respondJSON(w, http.StatusOK, *resp)
+}
+
 // ReassignReviewer переназначает ревьювера
 func (h *Handlers) ReassignReviewer(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodPost {
@@ -176,7 +708,7 @@ func (h *Handlers) ReassignReviewer(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	pr, replacedBy, err := h.service.ReassignReviewer(req.PullRequestID, req.OldUserID)
+	pr, replacedBy, err := h.svc(r).ReassignReviewer(req.PullRequestID, req.OldUserID)
 	if err != nil {
 		log.Printf("Error reassigning reviewer: %v", err)
 		status, code, msg := h.parseError(err)
@@ -190,6 +722,31 @@ func (h *Handlers) ReassignReviewer(w http.ResponseWriter, r *http.Request) {
 	})
 }
 
+// SubmitReview сохраняет вердикт ревьювера
+func (h *Handlers) SubmitReview(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		h.respondError(w, http.StatusMethodNotAllowed, "ERROR", "Method not allowed")
+		return
+	}
+
+	var req models.SubmitReviewRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		log.Printf("Error decoding request body: %v", err)
+		h.respondError(w, http.StatusBadRequest, "ERROR", "Invalid request body")
+		return
+	}
+
+	pr, err := h.svc(r).SubmitReview(&req)
+	if err != nil {
+		log.Printf("Error submitting review: %v", err)
+		status, code, msg := h.parseError(err)
+		h.respondError(w, status, code, msg)
+		return
+	}
+
+	h.respondJSON(w, http.StatusOK, models.PRResponse{PR: *pr})
+}
+
 // MergePR выполняет merge PR
 func (h *Handlers) MergePR(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodPost {
@@ -204,7 +761,7 @@ func (h *Handlers) MergePR(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	pr, err := h.service.MergePR(req.PullRequestID)
+	pr, err := h.svc(r).MergePR(req.PullRequestID)
 	if err != nil {
 		log.Printf("Error merging PR: %v", err)
 		status, code, msg := h.parseError(err)
@@ -215,6 +772,155 @@ func (h *Handlers) MergePR(w http.ResponseWriter, r *http.Request) {
 	h.respondJSON(w, http.StatusOK, models.PRResponse{PR: *pr})
 }
 
+// parsePRFilter читает фильтры списка PR: status, author_id, reviewer_id, team, label и границы дат
+func (h *Handlers) parsePRFilter(w http.ResponseWriter, r *http.Request) (models.PRFilter, bool) {
+	q := r.URL.Query()
+	filter := models.PRFilter{
+		Status:     q.Get("status"),
+		AuthorID:   q.Get("author_id"),
+		ReviewerID: q.Get("reviewer_id"),
+		TeamName:   q.Get("team"),
+		Labels:     q["label"],
+	}
+
+	var ok bool
+	if filter.CreatedFrom, ok = h.parseTimeParam(w, r, "created_from"); !ok {
+		return filter, false
+	}
+	if filter.CreatedTo, ok = h.parseTimeParam(w, r, "created_to"); !ok {
+		return filter, false
+	}
+	if filter.MergedFrom, ok = h.parseTimeParam(w, r, "merged_from"); !ok {
+		return filter, false
+	}
+	if filter.MergedTo, ok = h.parseTimeParam(w, r, "merged_to"); !ok {
+		return filter, false
+	}
+	return filter, true
+}
+
+// parseStatsFilter читает общие параметры статистики: team, from, to
+func (h *Handlers) parseStatsFilter(w http.ResponseWriter, r *http.Request) (models.StatsFilter, bool) {
+	filter := models.StatsFilter{TeamName: r.URL.Query().Get("team")}
+	var ok bool
+	if filter.From, ok = h.parseTimeParam(w, r, "from"); !ok {
+		return filter, false
+	}
+	if filter.To, ok = h.parseTimeParam(w, r, "to"); !ok {
+		return filter, false
+	}
+	return filter, true
+}
+
+// GetStats возвращает статистику назначений
+func (h *Handlers) GetStats(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		h.respondError(w, http.StatusMethodNotAllowed, "ERROR", "Method not allowed")
+		return
+	}
+
+	filter, ok := h.parseStatsFilter(w, r)
+	if !ok {
+		return
+	}
+
+	resp, err := h.svc(r).GetStats(filter)
+	if err != nil {
+		log.Printf("Error getting stats: %v", err)
+		status, code, msg := h.parseError(err)
+		h.respondError(w, status, code, msg)
+		return
+	}
+
+	h.respondJSON(w, http.StatusOK, *resp)
+}
+
+// GetStalePRs возвращает зависшие OPEN PR
+func (h *Handlers) GetStalePRs(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		h.respondError(w, http.StatusMethodNotAllowed, "ERROR", "Method not allowed")
+		return
+	}
+
+	olderThan, ok := h.parseDurationParam(w, r, "older_than", 7*24*time.Hour)
+	if !ok {
+		return
+	}
+
+	resp, err := h.svc(r).GetStalePRs(olderThan, r.URL.Query().Get("team"))
+	if err != nil {
+		log.Printf("Error getting stale PRs: %v", err)
+		status, code, msg := h.parseError(err)
+		h.respondError(w, status, code, msg)
+		return
+	}
+
+	h.respondJSON(w, http.StatusOK, *resp)
+}
+
+// GetAuditLog возвращает страницу журнала аудита
+func (h *Handlers) GetAuditLog(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		h.respondError(w, http.StatusMethodNotAllowed, "ERROR", "Method not allowed")
+		return
+	}
+
+	q := r.URL.Query()
+	filter := models.AuditFilter{
+		Actor:      q.Get("actor"),
+		EntityType: q.Get("entity_type"),
+		EntityID:   q.Get("entity_id"),
+	}
+	var ok bool
+	if filter.From, ok = h.parseTimeParam(w, r, "from"); !ok {
+		return
+	}
+	if filter.To, ok = h.parseTimeParam(w, r, "to"); !ok {
+		return
+	}
+	if filter.Limit, ok = h.parseLimit(w, r); !ok {
+		return
+	}
+
+	resp, err := h.svc(r).ListAuditLog(filter, q.Get("cursor"))
+	if err != nil {
+		log.Printf("Error listing audit log: %v", err)
+		status, code, msg := h.parseError(err)
+		h.respondError(w, status, code, msg)
+		return
+	}
+
+	h.respondJSON(w, http.StatusOK, *resp)
+}
+
+// GetCycleTime возвращает аналитику времени цикла PR
+func (h *Handlers) GetCycleTime(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		h.respondError(w, http.StatusMethodNotAllowed, "ERROR", "Method not allowed")
+		return
+	}
+
+	statsFilter, ok := h.parseStatsFilter(w, r)
+	if !ok {
+		return
+	}
+	filter := models.CycleTimeFilter{
+		StatsFilter: statsFilter,
+		GroupBy:     r.URL.Query().Get("group_by"),
+		Bucket:      r.URL.Query().Get("bucket"),
+	}
+
+	resp, err := h.svc(r).GetCycleTime(filter)
+	if err != nil {
+		log.Printf("Error getting cycle time: %v", err)
+		status, code, msg := h.parseError(err)
+		h.respondError(w, status, code, msg)
+		return
+	}
+
+	h.respondJSON(w, http.StatusOK, *resp)
+}
+
 // GetReview возвращает список PR, назначенных ревьюверу
 func (h *Handlers) GetReview(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodGet {
@@ -222,13 +928,25 @@ func (h *Handlers) GetReview(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	userID := r.URL.Query().Get("user_id")
+	q := r.URL.Query()
+	userID := q.Get("user_id")
 	if userID == "" {
 		h.respondError(w, http.StatusBadRequest, "ERROR", "user_id parameter is required")
 		return
 	}
 
-	resp, err := h.service.GetReview(userID)
+	filter := models.ReviewFilter{
+		ReviewerID: userID,
+		Status:     q.Get("status"),
+		Labels:     q["label"],
+		Sort:       q.Get("sort"),
+	}
+	var ok bool
+	if filter.Limit, ok = h.parseLimit(w, r); !ok {
+		return
+	}
+
+	resp, err := h.svc(r).GetReview(filter, q.Get("cursor"))
 	if err != nil {
 		log.Printf("Error getting review: %v", err)
 		status, code, msg := h.parseError(err)
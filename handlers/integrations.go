@@ -0,0 +1,197 @@
+package handlers
+
+import (
+	"avito/integrations"
+	"avito/models"
+	"avito/webhook"
+	"crypto/subtle"
+	"encoding/json"
+	"io"
+	"log"
+	"net/http"
+)
+
+// maxWebhookBody предельный размер тела входящего вебхука
+const maxWebhookBody = 5 << 20
+
+// SetGitHubWebhookSecret задает секрет для проверки подписи вебхуков GitHub; пустой - прием отключен
+func (h *Handlers) SetGitHubWebhookSecret(secret string) {
+	h.githubSecret = secret
+}
+
+// SetGitLabWebhookToken задает секретный токен вебхуков GitLab; пустой - прием отключен
+func (h *Handlers) SetGitLabWebhookToken(token string) {
+	h.gitlabToken = token
+}
+
+// GitHubWebhook принимает события pull_request из GitHub
+func (h *Handlers) GitHubWebhook(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		h.respondError(w, http.StatusMethodNotAllowed, "ERROR", "Method not allowed")
+		return
+	}
+	if h.githubSecret == "" {
+		h.respondError(w, http.StatusServiceUnavailable, "ERROR", "GitHub integration is not configured")
+		return
+	}
+
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxWebhookBody))
+	if err != nil {
+		h.respondError(w, http.StatusBadRequest, "ERROR", "Invalid request body")
+		return
+	}
+	if !webhook.Verify(h.githubSecret, body, r.Header.Get(integrations.GitHubSignatureHeader)) {
+		h.respondError(w, http.StatusUnauthorized, "ERROR", "invalid signature")
+		return
+	}
+
+	eventType := r.Header.Get(integrations.GitHubEventHeader)
+	ev, err := integrations.ParseGitHubEvent(eventType, body)
+	h.ingestEvent(w, r, eventType, ev, err)
+}
+
+// GitLabWebhook принимает события Merge Request Hook из GitLab
+func (h *Handlers) GitLabWebhook(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		h.respondError(w, http.StatusMethodNotAllowed, "ERROR", "Method not allowed")
+		return
+	}
+	if h.gitlabToken == "" {
+		h.respondError(w, http.StatusServiceUnavailable, "ERROR", "GitLab integration is not configured")
+		return
+	}
+	token := r.Header.Get(integrations.GitLabTokenHeader)
+	if subtle.ConstantTimeCompare([]byte(token), []byte(h.gitlabToken)) != 1 {
+		h.respondError(w, http.StatusUnauthorized, "ERROR", "invalid token")
+		return
+	}
+
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxWebhookBody))
+	if err != nil {
+		h.respondError(w, http.StatusBadRequest, "ERROR", "Invalid request body")
+		return
+	}
+
+	eventType := r.Header.Get(integrations.GitLabEventHeader)
+	ev, err := integrations.ParseGitLabEvent(eventType, body)
+	h.ingestEvent(w, r, eventType, ev, err)
+}
+
+// ingestEvent передает разобранное событие внешней системы в сервис от имени <provider>:<логин отправителя>;
+// parseErr - ошибка разбора тела, nil ev - событие не поддерживается
+func (h *Handlers) ingestEvent(w http.ResponseWriter, r *http.Request, eventType string, ev *models.CodeHostPREvent, parseErr error) {
+	if parseErr != nil {
+		log.Printf("Error parsing %s event: %v", eventType, parseErr)
+		h.respondError(w, http.StatusBadRequest, "ERROR", parseErr.Error())
+		return
+	}
+	if ev == nil {
+		h.respondJSON(w, http.StatusOK, models.IntegrationResult{Status: "ignored", Reason: "event " + eventType + " is not handled"})
+		return
+	}
+
+	result, err := h.svcAs(r, ev.Provider+":"+ev.SenderLogin).IngestPREvent(ev)
+	if err != nil {
+		log.Printf("Error handling %s %s event for %s: %v", ev.Provider, ev.Action, ev.PullRequestID, err)
+		status, code, msg := h.parseError(err)
+		h.respondError(w, status, code, msg)
+		return
+	}
+
+	h.respondJSON(w, http.StatusOK, *result)
+}
+
+// SetIdentity привязывает логин внешней системы к пользователю
+func (h *Handlers) SetIdentity(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		h.respondError(w, http.StatusMethodNotAllowed, "ERROR", "Method not allowed")
+		return
+	}
+
+	var req models.Identity
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		log.Printf("Error decoding request body: %v", err)
+		h.respondError(w, http.StatusBadRequest, "ERROR", "Invalid request body")
+		return
+	}
+
+	identity, err := h.svc(r).SetIdentity(&req)
+	if err != nil {
+		log.Printf("Error setting identity: %v", err)
+		status, code, msg := h.parseError(err)
+		h.respondError(w, status, code, msg)
+		return
+	}
+
+	h.respondJSON(w, http.StatusOK, *identity)
+}
+
+// ListIdentities возвращает привязки логинов
+func (h *Handlers) ListIdentities(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		h.respondError(w, http.StatusMethodNotAllowed, "ERROR", "Method not allowed")
+		return
+	}
+
+	identities, err := h.svc(r).ListIdentities(r.URL.Query().Get("provider"))
+	if err != nil {
+		log.Printf("Error listing identities: %v", err)
+		status, code, msg := h.parseError(err)
+		h.respondError(w, status, code, msg)
+		return
+	}
+
+	h.respondJSON(w, http.StatusOK, models.IdentityListResponse{Identities: identities})
+}
+
+// DeleteIdentity удаляет привязку логина
+func (h *Handlers) DeleteIdentity(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		h.respondError(w, http.StatusMethodNotAllowed, "ERROR", "Method not allowed")
+		return
+	}
+
+	var req models.DeleteIdentityRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		log.Printf("Error decoding request body: %v", err)
+		h.respondError(w, http.StatusBadRequest, "ERROR", "Invalid request body")
+		return
+	}
+
+	if err := h.svc(r).DeleteIdentity(&req); err != nil {
+		log.Printf("Error deleting identity: %v", err)
+		status, code, msg := h.parseError(err)
+		h.respondError(w, status, code, msg)
+		return
+	}
+
+	h.respondJSON(w, http.StatusOK, req)
+}
+
+// ListCodeHostSync возвращает очередь синхронизации ревьюверов с GitHub (в том числе неудачные задачи)
+func (h *Handlers) ListCodeHostSync(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		h.respondError(w, http.StatusMethodNotAllowed, "ERROR", "Method not allowed")
+		return
+	}
+
+	q := r.URL.Query()
+	filter := models.CodeHostSyncFilter{
+		PullRequestID: q.Get("pull_request_id"),
+		Status:        q.Get("status"),
+	}
+	var ok bool
+	if filter.Limit, ok = h.parseLimit(w, r); !ok {
+		return
+	}
+
+	resp, err := h.svc(r).ListCodeHostSync(filter, q.Get("cursor"))
+	if err != nil {
+		log.Printf("Error listing reviewer sync tasks: %v", err)
+		status, code, msg := h.parseError(err)
+		h.respondError(w, status, code, msg)
+		return
+	}
+
+	h.respondJSON(w, http.StatusOK, *resp)
+}
@@ -0,0 +1,84 @@
+package handlers
+
+import (
+	"avito/integrations"
+	"avito/models"
+	"avito/webhook"
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+const testGitHubSecret = "s3cret"
+
+func readGitHubFixture(t *testing.T, name string) []byte {
+	t.Helper()
+	body, err := os.ReadFile(filepath.Join("..", "integrations", "testdata", "github", name))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return body
+}
+
+// postGitHubWebhook отправляет событие в GitHubWebhook; сервис не задан, поэтому
+// до него доходить не должно
+func postGitHubWebhook(t *testing.T, eventType string, body []byte, signature string) *httptest.ResponseRecorder {
+	t.Helper()
+	h := NewHandlers(nil)
+	h.SetGitHubWebhookSecret(testGitHubSecret)
+
+	req := httptest.NewRequest(http.MethodPost, "/integrations/github", bytes.NewReader(body))
+	req.Header.Set(integrations.GitHubEventHeader, eventType)
+	if signature != "" {
+		req.Header.Set(integrations.GitHubSignatureHeader, signature)
+	}
+	rec := httptest.NewRecorder()
+	h.GitHubWebhook(rec, req)
+	return rec
+}
+
+func TestGitHubWebhookRejectsBadSignature(t *testing.T) {
+	body := readGitHubFixture(t, "pull_request_opened.json")
+	signature := webhook.Sign(testGitHubSecret, body)
+
+	tests := []struct {
+		name      string
+		body      []byte
+		signature string
+	}{
+		{"tampered body", bytes.Replace(body, []byte(`"additions": 120`), []byte(`"additions": 1`), 1), signature},
+		{"wrong secret", body, webhook.Sign("other", body)},
+		{"missing signature", body, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if bytes.Equal(tt.body, body) && tt.signature == signature {
+				t.Fatal("test case does not change the request")
+			}
+			rec := postGitHubWebhook(t, "pull_request", tt.body, tt.signature)
+			if rec.Code != http.StatusUnauthorized {
+				t.Fatalf("status = %d, want %d; body %s", rec.Code, http.StatusUnauthorized, rec.Body)
+			}
+		})
+	}
+}
+
+func TestGitHubWebhookIgnoresPing(t *testing.T) {
+	body := readGitHubFixture(t, "ping.json")
+	rec := postGitHubWebhook(t, "ping", body, webhook.Sign(testGitHubSecret, body))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, want %d; body %s", rec.Code, http.StatusOK, rec.Body)
+	}
+
+	var result models.IntegrationResult
+	if err := json.NewDecoder(rec.Body).Decode(&result); err != nil {
+		t.Fatal(err)
+	}
+	if result.Status != "ignored" {
+		t.Errorf("status = %q, want ignored", result.Status)
+	}
+}
@@ -0,0 +1,134 @@
+package handlers
+
+import (
+	"avito/models"
+	"encoding/json"
+	"log"
+	"net/http"
+	"strconv"
+)
+
+// CreateWebhook создает подписку на события
+func (h *Handlers) CreateWebhook(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		h.respondError(w, http.StatusMethodNotAllowed, "ERROR", "Method not allowed")
+		return
+	}
+
+	var req models.CreateWebhookRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		log.Printf("Error decoding request body: %v", err)
+		h.respondError(w, http.StatusBadRequest, "ERROR", "Invalid request body")
+		return
+	}
+
+	webhook, err := h.svc(r).CreateWebhook(&req)
+	if err != nil {
+		log.Printf("Error creating webhook: %v", err)
+		status, code, msg := h.parseError(err)
+		h.respondError(w, status, code, msg)
+		return
+	}
+
+	h.respondJSON(w, http.StatusCreated, models.WebhookResponse{Webhook: *webhook})
+}
+
+// ListWebhooks возвращает все подписки (без секретов)
+func (h *Handlers) ListWebhooks(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		h.respondError(w, http.StatusMethodNotAllowed, "ERROR", "Method not allowed")
+		return
+	}
+
+	webhooks, err := h.svc(r).ListWebhooks()
+	if err != nil {
+		log.Printf("Error listing webhooks: %v", err)
+		status, code, msg := h.parseError(err)
+		h.respondError(w, status, code, msg)
+		return
+	}
+
+	h.respondJSON(w, http.StatusOK, models.WebhookListResponse{Webhooks: webhooks})
+}
+
+// DeleteWebhook удаляет подписку
+func (h *Handlers) DeleteWebhook(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		h.respondError(w, http.StatusMethodNotAllowed, "ERROR", "Method not allowed")
+		return
+	}
+
+	var req models.DeleteWebhookRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		log.Printf("Error decoding request body: %v", err)
+		h.respondError(w, http.StatusBadRequest, "ERROR", "Invalid request body")
+		return
+	}
+
+	if err := h.svc(r).DeleteWebhook(req.WebhookID); err != nil {
+		log.Printf("Error deleting webhook: %v", err)
+		status, code, msg := h.parseError(err)
+		h.respondError(w, status, code, msg)
+		return
+	}
+
+	h.respondJSON(w, http.StatusOK, req)
+}
+
+// ListWebhookDeliveries возвращает журнал доставок
+func (h *Handlers) ListWebhookDeliveries(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		h.respondError(w, http.StatusMethodNotAllowed, "ERROR", "Method not allowed")
+		return
+	}
+
+	q := r.URL.Query()
+	filter := models.WebhookDeliveryFilter{Status: q.Get("status")}
+	if v := q.Get("webhook_id"); v != "" {
+		id, err := strconv.ParseInt(v, 10, 64)
+		if err != nil {
+			h.respondError(w, http.StatusBadRequest, "ERROR", "webhook_id must be an integer")
+			return
+		}
+		filter.WebhookID = id
+	}
+	var ok bool
+	if filter.Limit, ok = h.parseLimit(w, r); !ok {
+		return
+	}
+
+	resp, err := h.svc(r).ListWebhookDeliveries(filter, q.Get("cursor"))
+	if err != nil {
+		log.Printf("Error listing webhook deliveries: %v", err)
+		status, code, msg := h.parseError(err)
+		h.respondError(w, status, code, msg)
+		return
+	}
+
+	h.respondJSON(w, http.StatusOK, *resp)
+}
+
+// RedeliverWebhook повторно ставит событие в очередь доставки
+func (h *Handlers) RedeliverWebhook(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		h.respondError(w, http.StatusMethodNotAllowed, "ERROR", "Method not allowed")
+		return
+	}
+
+	var req models.RedeliverWebhookRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		log.Printf("Error decoding request body: %v", err)
+		h.respondError(w, http.StatusBadRequest, "ERROR", "Invalid request body")
+		return
+	}
+
+	delivery, err := h.svc(r).RedeliverWebhook(req.DeliveryID)
+	if err != nil {
+		log.Printf("Error redelivering webhook: %v", err)
+		status, code, msg := h.parseError(err)
+		h.respondError(w, status, code, msg)
+		return
+	}
+
+	h.respondJSON(w, http.StatusAccepted, models.WebhookDeliveryResponse{Delivery: *delivery})
+}
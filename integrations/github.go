@@ -0,0 +1,82 @@
+package integrations
+
+import (
+	"avito/models"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// Заголовки запросов вебхука GitHub
+const (
+	GitHubEventHeader     = "X-GitHub-Event"
+	GitHubSignatureHeader = "X-Hub-Signature-256"
+)
+
+// githubPullRequestEvent поля события pull_request, которые использует сервис
+type githubPullRequestEvent struct {
+	Action      string `json:"action"`
+	PullRequest struct {
+		Number       int    `json:"number"`
+		Title        string `json:"title"`
+		Merged       bool   `json:"merged"`
+		Draft        bool   `json:"draft"`
+		Additions    int    `json:"additions"`
+		Deletions    int    `json:"deletions"`
+		ChangedFiles int    `json:"changed_files"`
+		User         struct {
+			Login string `json:"login"`
+		} `json:"user"`
+		Labels []struct {
+			Name string `json:"name"`
+		} `json:"labels"`
+	} `json:"pull_request"`
+	Repository struct {
+		FullName string `json:"full_name"`
+	} `json:"repository"`
+	Sender struct {
+		Login string `json:"login"`
+	} `json:"sender"`
+}
+
+// ParseGitHubEvent разбирает тело вебхука GitHub. Для событий, отличных от pull_request,
+// возвращает nil без ошибки. Действия opened, reopened и closed сохраняются как есть, closed
+// со смерженным PR становится merged, ready_for_review - opened; остальные действия передаются
+// без изменений и игнорируются сервисом
+func ParseGitHubEvent(eventType string, body []byte) (*models.CodeHostPREvent, error) {
+	if eventType != "pull_request" {
+		return nil, nil
+	}
+
+	var payload githubPullRequestEvent
+	if err := json.Unmarshal(body, &payload); err != nil {
+		return nil, fmt.Errorf("invalid pull_request payload: %w", err)
+	}
+	if payload.Repository.FullName == "" || payload.PullRequest.Number == 0 {
+		return nil, fmt.Errorf("pull_request payload has no repository or number")
+	}
+
+	pr := payload.PullRequest
+	ev := &models.CodeHostPREvent{
+		Provider:      models.ProviderGitHub,
+		Action:        payload.Action,
+		PullRequestID: fmt.Sprintf("%s#%d", payload.Repository.FullName, pr.Number),
+		Title:         pr.Title,
+		AuthorLogin:   strings.ToLower(pr.User.Login),
+		SenderLogin:   strings.ToLower(payload.Sender.Login),
+		Draft:         pr.Draft,
+		Additions:     pr.Additions,
+		Deletions:     pr.Deletions,
+		FilesChanged:  pr.ChangedFiles,
+	}
+	for _, label := range pr.Labels {
+		ev.Labels = append(ev.Labels, label.Name)
+	}
+	switch {
+	case payload.Action == "closed" && pr.Merged:
+		ev.Action = models.CodeHostMerged
+	case payload.Action == "ready_for_review":
+		ev.Action = models.CodeHostOpened
+	}
+	return ev, nil
+}
@@ -0,0 +1,107 @@
+package integrations
+
+import (
+	"avito/models"
+	"avito/webhook"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+const testGitHubSecret = "s3cret"
+
+func readFixture(t *testing.T, provider, name string) []byte {
+	t.Helper()
+	body, err := os.ReadFile(filepath.Join("testdata", provider, name))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return body
+}
+
+func TestParseGitHubEvent(t *testing.T) {
+	labels := []string{"backend", "payments"}
+	tests := []struct {
+		name      string
+		fixture   string
+		eventType string
+		want      *models.CodeHostPREvent // nil - событие игнорируется
+	}{
+		{
+			name: "opened", fixture: "pull_request_opened.json", eventType: "pull_request",
+			want: &models.CodeHostPREvent{Provider: models.ProviderGitHub, Action: models.CodeHostOpened,
+				PullRequestID: "acme/payments#42", Title: "Retry failed refunds", AuthorLogin: "octo-dev", SenderLogin: "octo-dev",
+				Labels: labels, Additions: 120, Deletions: 35, FilesChanged: 6},
+		},
+		{
+			name: "reopened", fixture: "pull_request_reopened.json", eventType: "pull_request",
+			want: &models.CodeHostPREvent{Provider: models.ProviderGitHub, Action: models.CodeHostReopened,
+				PullRequestID: "acme/payments#42", Title: "Retry failed refunds", AuthorLogin: "octo-dev", SenderLogin: "octo-dev",
+				Labels: labels, Additions: 120, Deletions: 35, FilesChanged: 6},
+		},
+		{
+			name: "closed", fixture: "pull_request_closed.json", eventType: "pull_request",
+			want: &models.CodeHostPREvent{Provider: models.ProviderGitHub, Action: models.CodeHostClosed,
+				PullRequestID: "acme/payments#43", Title: "Experiment: async ledger", AuthorLogin: "octo-dev", SenderLogin: "octo-dev",
+				Additions: 410, Deletions: 12, FilesChanged: 14},
+		},
+		{
+			name: "closed and merged", fixture: "pull_request_closed_merged.json", eventType: "pull_request",
+			want: &models.CodeHostPREvent{Provider: models.ProviderGitHub, Action: models.CodeHostMerged,
+				PullRequestID: "acme/payments#42", Title: "Retry failed refunds", AuthorLogin: "octo-dev", SenderLogin: "octo-lead",
+				Labels: labels, Additions: 120, Deletions: 35, FilesChanged: 6},
+		},
+		{
+			name: "synchronize ignored", fixture: "pull_request_synchronize.json", eventType: "pull_request",
+			want: &models.CodeHostPREvent{Provider: models.ProviderGitHub, Action: "synchronize",
+				PullRequestID: "acme/payments#42", Title: "Retry failed refunds", AuthorLogin: "octo-dev", SenderLogin: "octo-dev",
+				Labels: labels, Additions: 128, Deletions: 35, FilesChanged: 6},
+		},
+		{
+			name: "ping ignored", fixture: "ping.json", eventType: "ping",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ev, err := ParseGitHubEvent(tt.eventType, readFixture(t, "github", tt.fixture))
+			if err != nil {
+				t.Fatalf("ParseGitHubEvent: %v", err)
+			}
+			if tt.want == nil {
+				if ev != nil {
+					t.Fatalf("event = %+v, want nil", ev)
+				}
+				return
+			}
+			if !reflect.DeepEqual(ev, tt.want) {
+				t.Errorf("event = %+v\nwant    %+v", ev, tt.want)
+			}
+		})
+	}
+}
+
+func TestVerifyRejectsBadSignature(t *testing.T) {
+	body := readFixture(t, "github", "pull_request_opened.json")
+	signature := webhook.Sign(testGitHubSecret, body)
+
+	tests := []struct {
+		name      string
+		secret    string
+		body      []byte
+		signature string
+	}{
+		{"wrong secret", "other", body, signature},
+		{"modified body", testGitHubSecret, append([]byte(" "), body...), signature},
+		{"missing signature", testGitHubSecret, body, ""},
+		{"no sha256 prefix", testGitHubSecret, body, signature[len("sha256="):]},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if webhook.Verify(tt.secret, tt.body, tt.signature) {
+				t.Error("Verify accepted a bad signature")
+			}
+		})
+	}
+}
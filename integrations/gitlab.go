@@ -0,0 +1,104 @@
+package integrations
+
+import (
+	"avito/models"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Заголовки запросов вебхука GitLab
+const (
+	GitLabEventHeader = "X-Gitlab-Event"
+	GitLabTokenHeader = "X-Gitlab-Token"
+)
+
+// gitlabDraftChange изменение признака черновика в событии update
+type gitlabDraftChange struct {
+	Previous bool `json:"previous"`
+	Current  bool `json:"current"`
+}
+
+// gitlabMergeRequestEvent поля события Merge Request Hook, которые использует сервис
+type gitlabMergeRequestEvent struct {
+	ObjectKind string `json:"object_kind"`
+	User       struct {
+		ID       int    `json:"id"`
+		Username string `json:"username"`
+	} `json:"user"`
+	Project struct {
+		PathWithNamespace string `json:"path_with_namespace"`
+	} `json:"project"`
+	ObjectAttributes struct {
+		IID            int    `json:"iid"`
+		Title          string `json:"title"`
+		Action         string `json:"action"`
+		AuthorID       int    `json:"author_id"`
+		Draft          bool   `json:"draft"`
+		WorkInProgress bool   `json:"work_in_progress"`
+	} `json:"object_attributes"`
+	Labels []struct {
+		Title string `json:"title"`
+	} `json:"labels"`
+	Changes struct {
+		Draft          *gitlabDraftChange `json:"draft"`
+		WorkInProgress *gitlabDraftChange `json:"work_in_progress"`
+	} `json:"changes"`
+}
+
+// ParseGitLabEvent разбирает тело вебхука GitLab. Для событий, отличных от Merge Request Hook,
+// возвращает nil без ошибки. Действия open, reopen, merge и close становятся opened, reopened,
+// merged и closed; update, снимающий признак черновика, - opened, а делающий MR черновиком игнорируется.
+// GitLab передает только числовой ID автора MR; логин известен, лишь если событие вызвал сам автор
+func ParseGitLabEvent(eventType string, body []byte) (*models.CodeHostPREvent, error) {
+	if eventType != "Merge Request Hook" {
+		return nil, nil
+	}
+
+	var payload gitlabMergeRequestEvent
+	if err := json.Unmarshal(body, &payload); err != nil {
+		return nil, fmt.Errorf("invalid merge request payload: %w", err)
+	}
+	if payload.Project.PathWithNamespace == "" || payload.ObjectAttributes.IID == 0 {
+		return nil, fmt.Errorf("merge request payload has no project or iid")
+	}
+
+	mr := payload.ObjectAttributes
+	login := strings.ToLower(payload.User.Username)
+	ev := &models.CodeHostPREvent{
+		Provider:         models.ProviderGitLab,
+		Action:           mr.Action,
+		PullRequestID:    fmt.Sprintf("%s!%d", payload.Project.PathWithNamespace, mr.IID),
+		Title:            mr.Title,
+		AuthorExternalID: strconv.Itoa(mr.AuthorID),
+		SenderLogin:      login,
+		Draft:            mr.Draft || mr.WorkInProgress,
+	}
+	if payload.User.ID == mr.AuthorID {
+		ev.AuthorLogin = login
+	}
+	for _, label := range payload.Labels {
+		ev.Labels = append(ev.Labels, label.Title)
+	}
+
+	switch mr.Action {
+	case "open":
+		ev.Action = models.CodeHostOpened
+	case "reopen":
+		ev.Action = models.CodeHostReopened
+	case "merge":
+		ev.Action = models.CodeHostMerged
+	case "close":
+		ev.Action = models.CodeHostClosed
+	case "update":
+		change := payload.Changes.Draft
+		if change == nil {
+			change = payload.Changes.WorkInProgress
+		}
+		if change != nil && change.Previous && !change.Current {
+			ev.Action = models.CodeHostOpened
+		}
+	}
+	return ev, nil
+}
@@ -0,0 +1,58 @@
+package integrations
+
+import (
+	"avito/models"
+	"testing"
+)
+
+func TestParseGitLabEvent(t *testing.T) {
+	tests := []struct {
+		fixture     string
+		action      string
+		authorLogin string
+		senderLogin string
+		draft       bool
+	}{
+		{"merge_request_open.json", models.CodeHostOpened, "dana", "dana", false},
+		{"merge_request_open_draft.json", models.CodeHostOpened, "dana", "dana", true},
+		{"merge_request_reopen.json", models.CodeHostReopened, "", "maintainer-oleg", false},
+		{"merge_request_merge.json", models.CodeHostMerged, "", "maintainer-oleg", false},
+		{"merge_request_close.json", models.CodeHostClosed, "dana", "dana", false},
+		{"merge_request_update_ready.json", models.CodeHostOpened, "dana", "dana", false},
+		{"merge_request_update_draft.json", "update", "dana", "dana", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.fixture, func(t *testing.T) {
+			ev, err := ParseGitLabEvent("Merge Request Hook", readFixture(t, "gitlab", tt.fixture))
+			if err != nil {
+				t.Fatalf("ParseGitLabEvent: %v", err)
+			}
+			if ev.Action != tt.action {
+				t.Errorf("action = %q, want %q", ev.Action, tt.action)
+			}
+			if ev.PullRequestID != "payments/billing-api!17" {
+				t.Errorf("pull_request_id = %q", ev.PullRequestID)
+			}
+			if ev.AuthorLogin != tt.authorLogin {
+				t.Errorf("author login = %q, want %q", ev.AuthorLogin, tt.authorLogin)
+			}
+			if ev.AuthorExternalID != "42" {
+				t.Errorf("author external ID = %q, want 42", ev.AuthorExternalID)
+			}
+			if ev.SenderLogin != tt.senderLogin {
+				t.Errorf("sender login = %q, want %q", ev.SenderLogin, tt.senderLogin)
+			}
+			if ev.Draft != tt.draft {
+				t.Errorf("draft = %v, want %v", ev.Draft, tt.draft)
+			}
+		})
+	}
+}
+
+func TestParseGitLabEventIgnoresOtherEvents(t *testing.T) {
+	ev, err := ParseGitLabEvent("Push Hook", []byte(`{"object_kind": "push"}`))
+	if err != nil || ev != nil {
+		t.Fatalf("ParseGitLabEvent = %v, %v; want nil, nil", ev, err)
+	}
+}
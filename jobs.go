@@ -0,0 +1,113 @@
+package main
+
+import (
+	"avito/notifier"
+	"avito/outbox"
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"time"
+)
+
+// runPeriodically запускает fn каждые interval до отмены ctx
+func runPeriodically(ctx context.Context, name string, interval time.Duration, fn func(context.Context) error) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				if err := fn(ctx); err != nil {
+					log.Printf("Job %s failed: %v", name, err)
+				}
+			}
+		}
+	}()
+}
+
+// durationFromEnv читает длительность из переменной окружения (формат time.ParseDuration)
+func durationFromEnv(name string, def time.Duration) time.Duration {
+	value := os.Getenv(name)
+	if value == "" {
+		return def
+	}
+
+	d, err := time.ParseDuration(value)
+	if err != nil || d <= 0 {
+		log.Printf("Warning: invalid %s=%q, using default %s", name, value, def)
+		return def
+	}
+	return d
+}
+
+// newNotifierFromEnv создает канал уведомлений согласно NOTIFY_SINK; несколько каналов
+// перечисляются через запятую (например, log,chat). directory нужен каналу chat.
+// Вторым значением возвращается канал уведомлений о назначениях и merge: их получает
+// только chat, без него (nil) такие уведомления выключены
+func newNotifierFromEnv(directory notifier.ChatDirectory) (notifier.Notifier, notifier.Notifier, error) {
+	var sinks notifier.MultiNotifier
+	var events notifier.Notifier
+	for _, sink := range strings.Split(os.Getenv("NOTIFY_SINK"), ",") {
+		sink = strings.TrimSpace(sink)
+		n, err := newNotifier(sink, directory)
+		if err != nil {
+			return nil, nil, err
+		}
+		if sink == "chat" {
+			events = n
+		}
+		sinks = append(sinks, n)
+	}
+	if len(sinks) == 1 {
+		return sinks[0], events, nil
+	}
+	return sinks, events, nil
+}
+
+func newNotifier(sink string, directory notifier.ChatDirectory) (notifier.Notifier, error) {
+	switch sink {
+	case "", "log":
+		return notifier.NewLogNotifier(os.Stdout), nil
+	case "file":
+		path := os.Getenv("NOTIFY_FILE")
+		if path == "" {
+			return nil, fmt.Errorf("NOTIFY_FILE is required for file sink")
+		}
+		return notifier.NewFileNotifier(path)
+	case "http":
+		url := os.Getenv("NOTIFY_URL")
+		if url == "" {
+			return nil, fmt.Errorf("NOTIFY_URL is required for http sink")
+		}
+		return notifier.NewHTTPNotifier(url, nil), nil
+	case "chat":
+		return notifier.NewChatNotifier(directory, os.Getenv("CHAT_WEBHOOK_URL"), os.Getenv("CHAT_FLAVOR"), nil)
+	case "none":
+		return notifier.NopNotifier{}, nil
+	default:
+		return nil, fmt.Errorf("unknown NOTIFY_SINK %q", sink)
+	}
+}
+
+// newOutboxSinkFromEnv создает получателя событий outbox согласно OUTBOX_SINK
+func newOutboxSinkFromEnv() (outbox.Sink, error) {
+	switch sink := os.Getenv("OUTBOX_SINK"); sink {
+	case "", "log":
+		return outbox.NewLogSink(os.Stdout), nil
+	case "http":
+		url := os.Getenv("OUTBOX_URL")
+		if url == "" {
+			return nil, fmt.Errorf("OUTBOX_URL is required for http sink")
+		}
+		return outbox.NewHTTPSink(url, nil), nil
+	case "none":
+		return outbox.NopSink{}, nil
+	default:
+		return nil, fmt.Errorf("unknown OUTBOX_SINK %q", sink)
+	}
+}
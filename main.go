@@ -1,13 +1,16 @@
 package main
 
 import (
+	"avito/codehost"
 	"avito/database"
 	"avito/handlers"
 	"avito/repository"
 	"avito/service"
+	"context"
 	"log"
 	"net/http"
 	"os"
+	"time"
 
 	"github.com/gorilla/mux"
 )
@@ -33,23 +36,123 @@ func main() {
 
 	repo := repository.NewRepository(db.DB)
 	svc := service.NewService(repo)
+
+	n, events, err := newNotifierFromEnv(svc)
+	if err != nil {
+		log.Fatalf("Failed to configure notifier: %v", err)
+	}
+	svc.SetNotifier(n)
+	svc.SetEventNotifier(events)
+
+	sink, err := newOutboxSinkFromEnv()
+	if err != nil {
+		log.Fatalf("Failed to configure outbox sink: %v", err)
+	}
+	svc.SetOutboxSink(sink)
+
+	// Синхронизация ревьюверов с GitHub включается токеном или адресом API (например, локальной заглушки)
+	githubToken, githubURL := os.Getenv("GITHUB_TOKEN"), os.Getenv("GITHUB_API_URL")
+	if githubToken != "" || githubURL != "" {
+		svc.SetCodeHostClient(codehost.NewGitHubClient(githubURL, githubToken, nil))
+	}
+
+	// Фоновые уведомления: ежедневная сводка и напоминания о долгих ревью
+	ctx := context.Background()
+	reminderThreshold := durationFromEnv("REMINDER_THRESHOLD", 48*time.Hour)
+	runPeriodically(ctx, "digest", durationFromEnv("DIGEST_INTERVAL", 24*time.Hour), svc.SendDigests)
+	runPeriodically(ctx, "reminders", durationFromEnv("REMINDER_CHECK_INTERVAL", time.Hour), func(ctx context.Context) error {
+		return svc.SendReminders(ctx, reminderThreshold)
+	})
+	// Уведомления в чат о назначениях, переназначениях и merge
+	runPeriodically(ctx, "event-notifications", durationFromEnv("EVENT_NOTIFY_INTERVAL", 5*time.Second), svc.SendEventNotifications)
+	// Закрытие неактивных PR; включается политикой команды auto_close_after_days
+	runPeriodically(ctx, "auto-close", durationFromEnv("AUTO_CLOSE_CHECK_INTERVAL", time.Hour), svc.CloseInactivePRs)
+	// Очистка журнала аудита старше срока хранения
+	auditRetention := durationFromEnv("AUDIT_RETENTION", 90*24*time.Hour)
+	runPeriodically(ctx, "audit-purge", durationFromEnv("AUDIT_PURGE_INTERVAL", 24*time.Hour), func(ctx context.Context) error {
+		return svc.PurgeAuditLog(ctx, auditRetention)
+	})
+	// Доставка событий outbox и очистка доставленных
+	runPeriodically(ctx, "outbox", durationFromEnv("OUTBOX_POLL_INTERVAL", time.Second), svc.DispatchOutbox)
+	outboxRetention := durationFromEnv("OUTBOX_RETENTION", 7*24*time.Hour)
+	runPeriodically(ctx, "outbox-purge", durationFromEnv("OUTBOX_PURGE_INTERVAL", 24*time.Hour), func(ctx context.Context) error {
+		return svc.PurgeOutbox(ctx, outboxRetention)
+	})
+	// Доставка исходящих вебхуков
+	runPeriodically(ctx, "webhooks", durationFromEnv("WEBHOOK_POLL_INTERVAL", 5*time.Second), svc.DeliverWebhooks)
+	runPeriodically(ctx, "codehost-sync", durationFromEnv("CODEHOST_SYNC_INTERVAL", 5*time.Second), svc.SyncCodeHost)
 	h := handlers.NewHandlers(svc)
+	h.SetGitHubWebhookSecret(os.Getenv("GITHUB_WEBHOOK_SECRET"))
+	h.SetGitLabWebhookToken(os.Getenv("GITLAB_WEBHOOK_TOKEN"))
 
 	r := mux.NewRouter()
+	r.Use(handlers.RequestID)
 
 	// Team endpoints
 	r.HandleFunc("/team/add", h.AddTeam).Methods("POST")
 	r.HandleFunc("/team/get", h.GetTeam).Methods("GET")
+	r.HandleFunc("/team", h.UpsertTeam).Methods("PUT")
+	r.HandleFunc("/team/setPolicy", h.SetTeamPolicy).Methods("POST")
+	r.HandleFunc("/team/setParent", h.SetTeamParent).Methods("POST")
+	r.HandleFunc("/team/setChatWebhook", h.SetTeamChatWebhook).Methods("POST")
+	r.HandleFunc("/team/tree", h.GetTeamTree).Methods("GET")
+	r.HandleFunc("/team/rename", h.RenameTeam).Methods("POST")
+	r.HandleFunc("/team/delete", h.DeleteTeam).Methods("POST")
+	r.HandleFunc("/team/addMember", h.AddTeamMember).Methods("POST")
+	r.HandleFunc("/team/removeMember", h.RemoveTeamMember).Methods("POST")
+	r.HandleFunc("/team/moveMember", h.MoveTeamMember).Methods("POST")
 
 	// User endpoints
 	r.HandleFunc("/users/setIsActive", h.SetUserActive).Methods("POST")
+	r.HandleFunc("/users/setReviewerProfile", h.SetReviewerProfile).Methods("POST")
+	r.HandleFunc("/users/setSkills", h.SetUserSkills).Methods("POST")
+	r.HandleFunc("/users/offboard", h.OffboardUser).Methods("POST")
+	r.HandleFunc("/users/get", h.GetUser).Methods("GET")
+	r.HandleFunc("/users/list", h.ListUsers).Methods("GET")
 
 	// PR endpoints
 	r.HandleFunc("/pullRequest/create", h.CreatePR).Methods("POST")
 	r.HandleFunc("/pullRequest/merge", h.MergePR).Methods("POST")
 	r.HandleFunc("/pullRequest/reassign", h.ReassignReviewer).Methods("POST")
+	r.HandleFunc("/pullRequest/review", h.SubmitReview).Methods("POST")
+	r.HandleFunc("/pullRequest/get", h.GetPR).Methods("GET")
+	r.HandleFunc("/pullRequest/list", h.ListPRs).Methods("GET")
+	r.HandleFunc("/pullRequest/stale", h.GetStalePRs).Methods("GET")
+	r.HandleFunc("/pullRequest/timeline", h.GetPRTimeline).Methods("GET")
 	r.HandleFunc("/users/getReview", h.GetReview).Methods("GET")
 
+	// Stats endpoints
+	r.HandleFunc("/stats", h.GetStats).Methods("GET")
+	r.HandleFunc("/stats/cycle-time", h.GetCycleTime).Methods("GET")
+
+	// Audit log
+	r.HandleFunc("/audit", h.GetAuditLog).Methods("GET")
+
+	// Webhook endpoints
+	r.HandleFunc("/webhooks", h.CreateWebhook).Methods("POST")
+	r.HandleFunc("/webhooks", h.ListWebhooks).Methods("GET")
+	r.HandleFunc("/webhooks/delete", h.DeleteWebhook).Methods("POST")
+	r.HandleFunc("/webhooks/deliveries", h.ListWebhookDeliveries).Methods("GET")
+	r.HandleFunc("/webhooks/redeliver", h.RedeliverWebhook).Methods("POST")
+
+	// Integrations
+	r.HandleFunc("/integrations/github/webhook", h.GitHubWebhook).Methods("POST")
+	r.HandleFunc("/integrations/gitlab/webhook", h.GitLabWebhook).Methods("POST")
+	r.HandleFunc("/integrations/github/sync", h.ListCodeHostSync).Methods("GET")
+	r.HandleFunc("/integrations/identities", h.SetIdentity).Methods("POST")
+	r.HandleFunc("/integrations/identities", h.ListIdentities).Methods("GET")
+	r.HandleFunc("/integrations/identities/delete", h.DeleteIdentity).Methods("POST")
+
+	// Export endpoints
+	r.HandleFunc("/export/prs", h.ExportPRs).Methods("GET")
+	r.HandleFunc("/export/assignments", h.ExportAssignments).Methods("GET")
+	r.HandleFunc("/export/stats", h.ExportStats).Methods("GET")
+
+	// Dashboard
+	r.HandleFunc("/dashboard", h.Dashboard).Methods("GET")
+	r.HandleFunc("/dashboard/setIsActive", h.DashboardSetUserActive).Methods("POST")
+	r.HandleFunc("/dashboard/reassign", h.DashboardReassign).Methods("POST")
+
 	// Health check
 	r.HandleFunc("/health", func(w http.ResponseWriter, r *http.Request) {
 		w.WriteHeader(http.StatusOK)
@@ -1,9 +1,44 @@
 package models
 
 import (
+	"encoding/json"
 	"time"
 )
 
+// Приоритеты PR
+const (
+	PriorityLow    = "low"
+	PriorityNormal = "normal"
+	PriorityHigh   = "high"
+	PriorityHotfix = "hotfix"
+)
+
+// Роли ревьюверов PR
+const (
+	RoleRequired = "required" // обязательный ревьювер
+	RoleOptional = "optional" // дополнительный ревьювер (например, для крупных PR)
+	RoleShadow   = "shadow"   // обучающийся ревьювер, не блокирует PR
+)
+
+// Обработка открытых ревью участника при удалении из команды
+const (
+	OpenReviewsKeep     = "keep"     // ревью остаются за пользователем
+	OpenReviewsReassign = "reassign" // ревью переназначаются внутри прежней команды
+)
+
+// Вердикты ревью
+const (
+	VerdictApproved         = "APPROVED"
+	VerdictChangesRequested = "CHANGES_REQUESTED"
+	VerdictCommented        = "COMMENTED"
+)
+
+// Сортировки очереди ревью
+const (
+	ReviewSortPriority = "priority" // по приоритету, внутри - от старых к новым
+	ReviewSortAge      = "age"      // от старых к новым
+)
+
 // TeamMember представляет участника команды
 type TeamMember struct {
 	UserID   string `json:"user_id" db:"user_id"`
@@ -11,43 +46,298 @@ type TeamMember struct {
 	IsActive bool   `json:"is_active" db:"is_active"`
 }
 
+// Типы событий PR
+const (
+	EventCreated          = "created"
+	EventReviewerAssigned = "reviewer_assigned"
+	EventReviewerReplaced = "reviewer_replaced"
+	EventReviewerRemoved  = "reviewer_removed"
+	EventReviewSubmitted  = "review_submitted"
+	EventMerged           = "merged"
+	EventClosed           = "closed"
+	EventReopened         = "reopened"
+)
+
+// Типы сущностей в журнале аудита
+const (
+	AuditEntityTeam = "team"
+	AuditEntityUser = "user"
+	AuditEntityPR   = "pull_request"
+)
+
+// Типы событий outbox
+const (
+	OutboxPRCreated        = "pr.created"
+	OutboxReviewerAssigned = "pr.reviewer_assigned"
+	OutboxReviewerReplaced = "pr.reviewer_replaced"
+	OutboxReviewerRemoved  = "pr.reviewer_removed"
+	OutboxReviewSubmitted  = "pr.review_submitted"
+	OutboxPRMerged         = "pr.merged"
+	OutboxPRClosed         = "pr.closed"
+	OutboxPRReopened       = "pr.reopened"
+	OutboxUserActivated    = "user.activated"
+	OutboxUserDeactivated  = "user.deactivated"
+	OutboxTeamChanged      = "team.changed"
+)
+
+// Статусы доставки вебхука
+const (
+	DeliveryPending   = "pending"
+	DeliveryDelivered = "delivered"
+	DeliveryFailed    = "failed" // попытки исчерпаны; доставку можно повторить вручную
+)
+
+// Внешние системы, логины в которых привязываются к пользователям
+const (
+	ProviderGitHub = "github"
+	ProviderGitLab = "gitlab"
+	ProviderChat   = "chat" // ник в Slack/Mattermost для упоминаний
+)
+
+// Действия с PR во внешней системе, приведенные к общему виду
+const (
+	CodeHostOpened   = "opened"
+	CodeHostReopened = "reopened"
+	CodeHostMerged   = "merged"
+	CodeHostClosed   = "closed"
+)
+
+// Действия синхронизации ревьюверов с внешней системой
+const (
+	SyncRequestReviewer = "request"
+	SyncRemoveReviewer  = "remove"
+)
+
+// Статусы синхронизации ревьюверов
+const (
+	SyncPending = "pending"
+	SyncDone    = "done"
+	SyncFailed  = "failed" // попытки исчерпаны или внешняя система отклонила запрос
+)
+
 // Team представляет команду
 type Team struct {
-	TeamName string       `json:"team_name" db:"team_name"`
-	Members  []TeamMember `json:"members"`
+	TeamName       string       `json:"team_name" db:"team_name"`
+	ParentTeamName string       `json:"parent_team_name,omitempty" db:"parent_team_name"`
+	Members        []TeamMember `json:"members"`
+	Policy         *TeamPolicy  `json:"policy,omitempty"`
+}
+
+// TeamSummary краткая информация о команде
+type TeamSummary struct {
+	TeamName       string `json:"team_name"`
+	ParentTeamName string `json:"parent_team_name,omitempty"`
+	MembersCount   int    `json:"members_count"`
+}
+
+// TeamNode узел дерева команд
+type TeamNode struct {
+	TeamName     string      `json:"team_name"`
+	MembersCount int         `json:"members_count"`
+	Children     []*TeamNode `json:"children"`
+}
+
+// TeamPolicy настройки назначения ревьюверов в команде
+type TeamPolicy struct {
+	LargePREffortThreshold int `json:"large_pr_effort_threshold" db:"large_pr_effort_threshold"` // 0 - политика выключена
+	LargePRExtraReviewers  int `json:"large_pr_extra_reviewers" db:"large_pr_extra_reviewers"`
+	AutoCloseAfterDays     int `json:"auto_close_after_days" db:"auto_close_after_days"` // 0 - автозакрытие выключено
 }
 
 // User представляет пользователя
 type User struct {
-	UserID   string `json:"user_id" db:"user_id"`
-	Username string `json:"username" db:"username"`
-	TeamName string `json:"team_name" db:"team_name"`
-	IsActive bool   `json:"is_active" db:"is_active"`
+	UserID             string     `json:"user_id" db:"user_id"`
+	Username           string     `json:"username" db:"username"`
+	TeamName           string     `json:"team_name" db:"team_name"`
+	IsActive           bool       `json:"is_active" db:"is_active"`
+	IsLearningReviewer bool       `json:"is_learning_reviewer" db:"is_learning_reviewer"`
+	IsSenior           bool       `json:"is_senior" db:"is_senior"`
+	Teams              []string   `json:"teams,omitempty"`
+	Skills             []string   `json:"skills,omitempty"`
+	DepartedAt         *time.Time `json:"departed_at,omitempty"` // время увольнения; такой пользователь не назначается ревьювером
+	OpenReviews        int        `json:"open_reviews"`          // количество OPEN PR на ревью
+	ReviewLoad         int        `json:"review_load"`           // суммарная трудоемкость OPEN PR на ревью без shadow
+}
+
+// UserFilter фильтры списка пользователей
+type UserFilter struct {
+	UserID         string
+	TeamName       string
+	IsActive       *bool
+	Skill          string
+	UsernamePrefix string
+	AfterUserID    string // курсор: user_id последнего пользователя предыдущей страницы
+	Limit          int    // 0 - без ограничения
 }
 
 // PullRequest представляет Pull Request
 type PullRequest struct {
-	PullRequestID     string     `json:"pull_request_id" db:"pull_request_id"`
-	PullRequestName   string     `json:"pull_request_name" db:"pull_request_name"`
-	AuthorID          string     `json:"author_id" db:"author_id"`
-	Status            string     `json:"status" db:"status"` // OPEN или MERGED
-	AssignedReviewers []string   `json:"assigned_reviewers"` // Список user_id ревьюверов (0..2)
-	CreatedAt         *time.Time `json:"createdAt,omitempty" db:"created_at"`
-	MergedAt          *time.Time `json:"mergedAt,omitempty" db:"merged_at"`
+	PullRequestID     string       `json:"pull_request_id" db:"pull_request_id"`
+	PullRequestName   string       `json:"pull_request_name" db:"pull_request_name"`
+	AuthorID          string       `json:"author_id" db:"author_id"`
+	Status            string       `json:"status" db:"status"`     // OPEN, MERGED или CLOSED
+	AssignedReviewers []string     `json:"assigned_reviewers"`     // user_id ревьюверов без shadow (0..2, больше для крупных PR по политике команды)
+	Priority          string       `json:"priority" db:"priority"` // low, normal, high или hotfix
+	Reviewers         []PRReviewer `json:"reviewers"`              // Все ревьюверы с ролями, включая shadow
+	Labels            []string     `json:"labels"`
+	Additions         int          `json:"additions" db:"additions"`
+	Deletions         int          `json:"deletions" db:"deletions"`
+	FilesChanged      int          `json:"files_changed" db:"files_changed"`
+	ReviewEffort      int          `json:"review_effort" db:"review_effort"`
+	CreatedAt         *time.Time   `json:"createdAt,omitempty" db:"created_at"`
+	MergedAt          *time.Time   `json:"mergedAt,omitempty" db:"merged_at"`
+	ClosedAt          *time.Time   `json:"closedAt,omitempty" db:"closed_at"` // время закрытия без merge
+}
+
+// PRReviewer ревьювер PR с ролью
+type PRReviewer struct {
+	UserID     string     `json:"user_id" db:"reviewer_id"`
+	Role       string     `json:"role" db:"role"` // required, optional или shadow
+	AssignedAt *time.Time `json:"assigned_at,omitempty" db:"assigned_at"`
+	Verdict    string     `json:"verdict,omitempty" db:"verdict"`
+	ReviewedAt *time.Time `json:"reviewed_at,omitempty" db:"reviewed_at"`
+}
+
+// PRFilter фильтры списка PR. Все условия объединяются через AND
+type PRFilter struct {
+	Status      string
+	AuthorID    string
+	ReviewerID  string
+	TeamName    string // команда автора
+	CreatedFrom *time.Time
+	CreatedTo   *time.Time
+	MergedFrom  *time.Time
+	MergedTo    *time.Time
+	Labels      []string // PR должен иметь все указанные метки
+	// Курсор: ключ сортировки последнего PR предыдущей страницы (created_at DESC, pull_request_id DESC)
+	AfterCreatedAt *time.Time
+	AfterID        string
+	Limit          int
 }
 
 // PullRequestShort представляет краткую информацию о PR
 type PullRequestShort struct {
-	PullRequestID   string `json:"pull_request_id"`
-	PullRequestName string `json:"pull_request_name"`
-	AuthorID        string `json:"author_id"`
-	Status          string `json:"status"`
+	PullRequestID   string   `json:"pull_request_id"`
+	PullRequestName string   `json:"pull_request_name"`
+	AuthorID        string   `json:"author_id"`
+	Status          string   `json:"status"`
+	Priority        string   `json:"priority"`
+	Labels          []string `json:"labels,omitempty"`
+}
+
+// ReviewItem PR в очереди ревьювера вместе с данными его назначения
+type ReviewItem struct {
+	PullRequestShort
+	CreatedAt  time.Time  `json:"createdAt"`
+	Role       string     `json:"role"`
+	AssignedAt time.Time  `json:"assigned_at"`
+	Verdict    string     `json:"verdict,omitempty"`
+	ReviewedAt *time.Time `json:"reviewed_at,omitempty"`
+}
+
+// ReviewFilter фильтры очереди ревьювера
+type ReviewFilter struct {
+	ReviewerID string
+	Status     string   // пусто - любой статус
+	Labels     []string // PR должен иметь все указанные метки
+	Sort       string   // priority или age
+	// Курсор: ключ сортировки последнего PR предыдущей страницы
+	AfterPriority  int
+	AfterCreatedAt *time.Time
+	AfterID        string
+	Limit          int // 0 - без ограничения
+}
+
+// PendingReview назначение ревьювера на открытый PR
+type PendingReview struct {
+	ReviewerID string
+	AssignedAt time.Time
+	PR         PullRequestShort
 }
 
 // CreateTeamRequest запрос на создание команды
 type CreateTeamRequest struct {
-	TeamName string       `json:"team_name"`
-	Members  []TeamMember `json:"members"`
+	TeamName       string       `json:"team_name"`
+	ParentTeamName string       `json:"parent_team_name,omitempty"`
+	Members        []TeamMember `json:"members"`
+}
+
+// UpsertTeamRequest декларативное описание команды: после применения состав команды
+// совпадает с members. parent_team_name меняется, только если передан
+type UpsertTeamRequest struct {
+	TeamName       string       `json:"team_name"`
+	ParentTeamName *string      `json:"parent_team_name,omitempty"`
+	Members        []TeamMember `json:"members"`
+	OpenReviews    string       `json:"open_reviews,omitempty"` // keep (по умолчанию) или reassign для удаляемых участников
+}
+
+// RenameTeamRequest запрос на переименование команды
+type RenameTeamRequest struct {
+	TeamName    string `json:"team_name"`
+	NewTeamName string `json:"new_team_name"`
+}
+
+// DeleteTeamRequest запрос на удаление команды. Если у участников есть OPEN ревью,
+// удаление возможно только с reassign_to - командой, на участников которой они переназначаются
+type DeleteTeamRequest struct {
+	TeamName   string `json:"team_name"`
+	ReassignTo string `json:"reassign_to,omitempty"`
+}
+
+// AddTeamMemberRequest запрос на добавление участника в команду.
+// Для нового пользователя username обязателен, is_active по умолчанию true
+type AddTeamMemberRequest struct {
+	TeamName string `json:"team_name"`
+	UserID   string `json:"user_id"`
+	Username string `json:"username,omitempty"`
+	IsActive *bool  `json:"is_active,omitempty"`
+}
+
+// RemoveTeamMemberRequest запрос на удаление участника из команды
+type RemoveTeamMemberRequest struct {
+	TeamName    string `json:"team_name"`
+	UserID      string `json:"user_id"`
+	OpenReviews string `json:"open_reviews,omitempty"` // keep (по умолчанию) или reassign
+}
+
+// MoveTeamMemberRequest запрос на перевод участника в другую команду
+type MoveTeamMemberRequest struct {
+	UserID      string `json:"user_id"`
+	FromTeam    string `json:"from_team"`
+	ToTeam      string `json:"to_team"`
+	OpenReviews string `json:"open_reviews,omitempty"` // keep (по умолчанию) или reassign
+}
+
+// SetTeamParentRequest запрос на перенос команды в иерархии (пустой parent_team_name - корень)
+type SetTeamParentRequest struct {
+	TeamName       string `json:"team_name"`
+	ParentTeamName string `json:"parent_team_name"`
+}
+
+// SetTeamPolicyRequest запрос на изменение политики команды (не переданные поля не меняются)
+type SetTeamPolicyRequest struct {
+	TeamName               string `json:"team_name"`
+	LargePREffortThreshold *int   `json:"large_pr_effort_threshold,omitempty"`
+	LargePRExtraReviewers  *int   `json:"large_pr_extra_reviewers,omitempty"`
+	AutoCloseAfterDays     *int   `json:"auto_close_after_days,omitempty"`
+}
+
+// SetReviewerProfileRequest запрос на изменение профиля ревьювера (не переданные поля не меняются)
+type SetReviewerProfileRequest struct {
+	UserID             string `json:"user_id"`
+	IsLearningReviewer *bool  `json:"is_learning_reviewer,omitempty"`
+	IsSenior           *bool  `json:"is_senior,omitempty"`
+}
+
+// SetUserSkillsRequest запрос на замену списка навыков пользователя
+type SetUserSkillsRequest struct {
+	UserID string   `json:"user_id"`
+	Skills []string `json:"skills"`
+}
+
+// OffboardUserRequest запрос на увольнение пользователя
+type OffboardUserRequest struct {
+	UserID string `json:"user_id"`
 }
 
 // SetUserActiveRequest запрос на установку активности пользователя
@@ -58,9 +348,21 @@ type SetUserActiveRequest struct {
 
 // CreatePRRequest запрос на создание PR
 type CreatePRRequest struct {
-	PullRequestID   string `json:"pull_request_id"`
-	PullRequestName string `json:"pull_request_name"`
-	AuthorID        string `json:"author_id"`
+	PullRequestID   string   `json:"pull_request_id"`
+	PullRequestName string   `json:"pull_request_name"`
+	AuthorID        string   `json:"author_id"`
+	Priority        string   `json:"priority,omitempty"`
+	Labels          []string `json:"labels,omitempty"`
+	Additions       int      `json:"additions,omitempty"`
+	Deletions       int      `json:"deletions,omitempty"`
+	FilesChanged    int      `json:"files_changed,omitempty"`
+}
+
+// SubmitReviewRequest запрос на вынесение вердикта ревьювером
+type SubmitReviewRequest struct {
+	PullRequestID string `json:"pull_request_id"`
+	ReviewerID    string `json:"reviewer_id"`
+	Verdict       string `json:"verdict"` // APPROVED, CHANGES_REQUESTED или COMMENTED
 }
 
 // MergePRRequest запрос на merge PR
@@ -90,16 +392,82 @@ type TeamResponse struct {
 	Team Team `json:"team"`
 }
 
+// TeamTreeResponse ответ с деревом команд
+type TeamTreeResponse struct {
+	Teams []*TeamNode `json:"teams"`
+}
+
+// ReviewReassignment переназначение ревью с одного пользователя на другого
+type ReviewReassignment struct {
+	PullRequestID string `json:"pull_request_id"`
+	OldUserID     string `json:"old_user_id"`
+	NewUserID     string `json:"new_user_id"`
+}
+
+// TeamDiff изменения, внесенные декларативным обновлением команды
+type TeamDiff struct {
+	TeamCreated bool                 `json:"team_created"`
+	Added       []string             `json:"added"`     // user_id новых участников
+	Updated     []string             `json:"updated"`   // user_id участников с измененными username/is_active
+	Removed     []string             `json:"removed"`   // user_id удаленных из команды
+	Unchanged   []string             `json:"unchanged"` // user_id участников без изменений
+	Reassigned  []ReviewReassignment `json:"reassigned"`
+}
+
+// UpsertTeamResponse ответ на декларативное обновление команды
+type UpsertTeamResponse struct {
+	Team Team     `json:"team"`
+	Diff TeamDiff `json:"diff"`
+}
+
+// DeleteTeamResponse ответ на удаление команды
+type DeleteTeamResponse struct {
+	TeamName   string               `json:"team_name"`
+	Reassigned []ReviewReassignment `json:"reassigned"`
+}
+
+// RemoveTeamMemberResponse ответ на удаление участника из команды
+type RemoveTeamMemberResponse struct {
+	Team       Team                 `json:"team"`
+	Reassigned []ReviewReassignment `json:"reassigned"`
+}
+
+// MoveTeamMemberResponse ответ на перевод участника в другую команду
+type MoveTeamMemberResponse struct {
+	FromTeam   Team                 `json:"from_team"`
+	ToTeam     Team                 `json:"to_team"`
+	Reassigned []ReviewReassignment `json:"reassigned"`
+}
+
 // UserResponse ответ с пользователем
 type UserResponse struct {
 	User User `json:"user"`
 }
 
+// UserListResponse страница списка пользователей
+type UserListResponse struct {
+	Users      []User `json:"users"`
+	NextCursor string `json:"next_cursor,omitempty"`
+}
+
+// OffboardUserResponse ответ на увольнение пользователя
+type OffboardUserResponse struct {
+	User       User                 `json:"user"`
+	Reassigned []ReviewReassignment `json:"reassigned"`
+	Removed    []string             `json:"removed"` // PR, с которых ревьювер снят без замены
+}
+
 // PRResponse ответ с PR
 type PRResponse struct {
 	PR PullRequest `json:"pr"`
 }
 
+// PRListResponse страница списка PR
+type PRListResponse struct {
+	PullRequests []PullRequest `json:"pull_requests"`
+	NextCursor   string        `json:"next_cursor,omitempty"`
+}
+
 // ReassignResponse ответ на переназначение
 type ReassignResponse struct {
 	PR         PullRequest `json:"pr"`
@@ -108,6 +476,345 @@ type ReassignResponse struct {
 
 // GetReviewResponse ответ на получение PR пользователя
 type GetReviewResponse struct {
-	UserID       string             `json:"user_id"`
-	PullRequests []PullRequestShort `json:"pull_requests"`
+	UserID       string       `json:"user_id"`
+	PullRequests []ReviewItem `json:"pull_requests"`
+	NextCursor   string       `json:"next_cursor,omitempty"`
+}
+
+// StatsFilter фильтры статистики; границы периода: [From, To)
+type StatsFilter struct {
+	TeamName string
+	From     *time.Time
+	To       *time.Time
+}
+
+// UserAssignmentStats статистика назначений пользователя
+type UserAssignmentStats struct {
+	UserID         string `json:"user_id"`
+	Username       string `json:"username"`
+	Assigned       int    `json:"assigned"`        // назначения, сделанные в периоде, включая потом переназначенные
+	OpenReviews    int    `json:"open_reviews"`    // текущие назначения на OPEN PR
+	ReassignedFrom int    `json:"reassigned_from"` // сколько раз ревью забирали у пользователя
+	ReassignedTo   int    `json:"reassigned_to"`   // сколько раз ревью передавали пользователю
+}
+
+// PRAssignmentStats статистика назначений по PR
+type PRAssignmentStats struct {
+	PullRequestID string `json:"pull_request_id"`
+	AuthorID      string `json:"author_id"`
+	Status        string `json:"status"`
+	Reviewers     int    `json:"reviewers"`
+	Reassignments int    `json:"reassignments"`
+}
+
+// TeamPRStats статистика PR команды (по команде автора)
+type TeamPRStats struct {
+	TeamName      string `json:"team_name"`
+	PRsOpened     int    `json:"prs_opened"`
+	PRsMerged     int    `json:"prs_merged"`
+	Reassignments int    `json:"reassignments"`
+}
+
+// StatsResponse ответ со статистикой назначений
+type StatsResponse struct {
+	Users        []UserAssignmentStats `json:"users"`
+	PullRequests []PRAssignmentStats   `json:"pull_requests"`
+	Teams        []TeamPRStats         `json:"teams"`
+}
+
+// CycleTimeFilter параметры аналитики времени цикла
+type CycleTimeFilter struct {
+	StatsFilter
+	GroupBy string // team или author
+	Bucket  string // day, week, month; пусто - без временного ряда
+}
+
+// DurationStats перцентили длительности в часах
+type DurationStats struct {
+	Key      string  `json:"key"` // команда, автор или ревьювер
+	Count    int     `json:"count"`
+	P50Hours float64 `json:"p50_hours"`
+	P90Hours float64 `json:"p90_hours"`
+}
+
+// DurationBucket перцентили длительности за интервал времени
+type DurationBucket struct {
+	BucketStart time.Time `json:"bucket_start"`
+	Count       int       `json:"count"`
+	P50Hours    float64   `json:"p50_hours"`
+	P90Hours    float64   `json:"p90_hours"`
+}
+
+// CycleTimeResponse ответ с аналитикой времени цикла
+type CycleTimeResponse struct {
+	GroupBy           string           `json:"group_by"`
+	TimeToMerge       []DurationStats  `json:"time_to_merge"`
+	TimeToFirstReview []DurationStats  `json:"time_to_first_review"` // по ревьюверам: от назначения до вердикта
+	Series            []DurationBucket `json:"series,omitempty"`     // время до merge по интервалам merged_at
+}
+
+// StalePR открытый PR без движения
+type StalePR struct {
+	PullRequest
+	LastActivityAt time.Time `json:"last_activity_at"` // последнее из: создание, назначение ревьювера, вердикт
+}
+
+// StalePRsResponse ответ со списком зависших PR
+type StalePRsResponse struct {
+	PullRequests []StalePR `json:"pull_requests"`
+}
+
+// DashboardTeam команда на панели нагрузки вместе с участниками
+type DashboardTeam struct {
+	TeamName       string `json:"team_name"`
+	ParentTeamName string `json:"parent_team_name,omitempty"`
+	Members        []User `json:"members"`
+}
+
+// Dashboard данные панели нагрузки ревьюверов
+type Dashboard struct {
+	Teams    []DashboardTeam `json:"teams"`
+	StalePRs []StalePR       `json:"stale_prs"`
+}
+
+// PRExportRow PR в выгрузке
+type PRExportRow struct {
+	PullRequestID     string     `json:"pull_request_id"`
+	PullRequestName   string     `json:"pull_request_name"`
+	AuthorID          string     `json:"author_id"`
+	Status            string     `json:"status"`
+	Priority          string     `json:"priority"`
+	Labels            []string   `json:"labels"`
+	AssignedReviewers []string   `json:"assigned_reviewers"` // без shadow
+	Additions         int        `json:"additions"`
+	Deletions         int        `json:"deletions"`
+	FilesChanged      int        `json:"files_changed"`
+	ReviewEffort      int        `json:"review_effort"`
+	CreatedAt         time.Time  `json:"created_at"`
+	MergedAt          *time.Time `json:"merged_at"`
+	ClosedAt          *time.Time `json:"closed_at"`
+}
+
+// AssignmentExportRow назначение ревьювера в выгрузке
+type AssignmentExportRow struct {
+	PullRequestID string     `json:"pull_request_id"`
+	AuthorID      string     `json:"author_id"`
+	PRStatus      string     `json:"pr_status"`
+	ReviewerID    string     `json:"reviewer_id"`
+	Role          string     `json:"role"`
+	AssignedAt    time.Time  `json:"assigned_at"`
+	Verdict       string     `json:"verdict"`
+	ReviewedAt    *time.Time `json:"reviewed_at"`
+}
+
+// PREvent событие в истории PR
+type PREvent struct {
+	ID            int64           `json:"id"`
+	PullRequestID string          `json:"pull_request_id"`
+	Type          string          `json:"type"`
+	Actor         string          `json:"actor"`
+	Payload       json.RawMessage `json:"payload"`
+	CreatedAt     time.Time       `json:"created_at"`
+}
+
+// PRTimelineResponse история PR в порядке событий
+type PRTimelineResponse struct {
+	PullRequestID string    `json:"pull_request_id"`
+	Events        []PREvent `json:"events"`
+}
+
+// AuditEntry запись журнала аудита
+type AuditEntry struct {
+	ID         int64           `json:"id"`
+	Actor      string          `json:"actor"`
+	Endpoint   string          `json:"endpoint"`
+	RequestID  string          `json:"request_id"`
+	EntityType string          `json:"entity_type"` // team, user или pull_request
+	EntityID   string          `json:"entity_id"`
+	Before     json.RawMessage `json:"before"` // null, если сущность создана
+	After      json.RawMessage `json:"after"`  // null, если сущность удалена
+	CreatedAt  time.Time       `json:"created_at"`
+}
+
+// AuditFilter фильтры журнала аудита
+type AuditFilter struct {
+	Actor      string
+	EntityType string
+	EntityID   string
+	From       *time.Time
+	To         *time.Time
+	BeforeID   int64 // курсор: id последней записи предыдущей страницы
+	Limit      int
+}
+
+// AuditListResponse страница журнала аудита
+type AuditListResponse struct {
+	Entries    []AuditEntry `json:"entries"`
+	NextCursor string       `json:"next_cursor,omitempty"`
+}
+
+// OutboxEvent событие для публикации во внешние системы.
+// События одного агрегата (aggregate_type + aggregate_id) доставляются в порядке id
+type OutboxEvent struct {
+	ID            int64           `json:"id"`
+	Type          string          `json:"type"`
+	AggregateType string          `json:"aggregate_type"` // team, user или pull_request
+	AggregateID   string          `json:"aggregate_id"`
+	Payload       json.RawMessage `json:"payload"`
+	CreatedAt     time.Time       `json:"created_at"`
+	Attempts      int             `json:"-"`
+}
+
+// Webhook подписка на события outbox
+type Webhook struct {
+	ID        int64     `json:"webhook_id"`
+	URL       string    `json:"url"`
+	Secret    string    `json:"-"`
+	Events    []string  `json:"events"` // типы событий или шаблоны вида pr.*; пустой список - все события
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// CreateWebhookRequest запрос на создание подписки
+type CreateWebhookRequest struct {
+	URL    string   `json:"url"`
+	Secret string   `json:"secret"`
+	Events []string `json:"events"`
+}
+
+// DeleteWebhookRequest запрос на удаление подписки
+type DeleteWebhookRequest struct {
+	WebhookID int64 `json:"webhook_id"`
+}
+
+// WebhookResponse ответ с подпиской
+type WebhookResponse struct {
+	Webhook Webhook `json:"webhook"`
+}
+
+// WebhookListResponse список подписок
+type WebhookListResponse struct {
+	Webhooks []Webhook `json:"webhooks"`
+}
+
+// WebhookDelivery доставка события подписчику
+type WebhookDelivery struct {
+	ID             int64           `json:"delivery_id"`
+	WebhookID      int64           `json:"webhook_id"`
+	EventID        int64           `json:"event_id"`
+	EventType      string          `json:"event_type"`
+	Payload        json.RawMessage `json:"payload"`
+	Status         string          `json:"status"` // pending, delivered или failed
+	Attempts       int             `json:"attempts"`
+	LastStatusCode *int            `json:"last_status_code,omitempty"`
+	LastError      string          `json:"last_error,omitempty"`
+	NextAttemptAt  *time.Time      `json:"next_attempt_at,omitempty"` // только для pending
+	CreatedAt      time.Time       `json:"created_at"`
+	DeliveredAt    *time.Time      `json:"delivered_at,omitempty"`
+}
+
+// WebhookDeliveryFilter фильтры журнала доставок
+type WebhookDeliveryFilter struct {
+	WebhookID int64
+	Status    string
+	BeforeID  int64 // курсор: id последней доставки предыдущей страницы
+	Limit     int
+}
+
+// WebhookDeliveryListResponse страница журнала доставок
+type WebhookDeliveryListResponse struct {
+	Deliveries []WebhookDelivery `json:"deliveries"`
+	NextCursor string            `json:"next_cursor,omitempty"`
+}
+
+// RedeliverWebhookRequest запрос на повторную доставку
+type RedeliverWebhookRequest struct {
+	DeliveryID int64 `json:"delivery_id"`
+}
+
+// WebhookDeliveryResponse ответ с доставкой
+type WebhookDeliveryResponse struct {
+	Delivery WebhookDelivery `json:"delivery"`
+}
+
+// Identity логин пользователя во внешней системе
+type Identity struct {
+	Provider   string `json:"provider"`
+	Login      string `json:"login"`
+	UserID     string `json:"user_id"`
+	ExternalID string `json:"external_id,omitempty"` // числовой ID пользователя во внешней системе (GitLab)
+}
+
+// IdentityListResponse список логинов
+type IdentityListResponse struct {
+	Identities []Identity `json:"identities"`
+}
+
+// DeleteIdentityRequest запрос на удаление логина
+type DeleteIdentityRequest struct {
+	Provider string `json:"provider"`
+	Login    string `json:"login"`
+}
+
+// CodeHostPREvent событие PR из внешней системы, приведенное к общему виду
+type CodeHostPREvent struct {
+	Provider         string
+	Action           string // opened, reopened, merged, closed; прочие игнорируются
+	PullRequestID    string // идентификатор PR в сервисе, например owner/repo#42
+	Title            string
+	AuthorLogin      string // "" - логин автора неизвестен, автор ищется по AuthorExternalID
+	AuthorExternalID string
+	SenderLogin      string
+	Draft            bool // черновик: ревьюверы не назначаются, пока он не готов к ревью
+	Labels           []string
+	Additions        int
+	Deletions        int
+	FilesChanged     int
+}
+
+// IntegrationResult результат обработки события внешней системы
+type IntegrationResult struct {
+	Status        string `json:"status"` // created, reopened, merged, closed или ignored
+	PullRequestID string `json:"pull_request_id,omitempty"`
+	Reason        string `json:"reason,omitempty"`
+}
+
+// CodeHostSyncTask запрос или снятие ревьювера PR во внешней системе
+type CodeHostSyncTask struct {
+	ID            int64      `json:"id"`
+	PullRequestID string     `json:"pull_request_id"`
+	Action        string     `json:"action"` // request или remove
+	UserID        string     `json:"user_id"`
+	Status        string     `json:"status"` // pending, done или failed
+	Attempts      int        `json:"attempts"`
+	LastError     string     `json:"last_error,omitempty"`
+	NextAttemptAt *time.Time `json:"next_attempt_at,omitempty"` // только для pending
+	CreatedAt     time.Time  `json:"created_at"`
+	FinishedAt    *time.Time `json:"finished_at,omitempty"`
+}
+
+// CodeHostSyncFilter фильтры очереди синхронизации
+type CodeHostSyncFilter struct {
+	PullRequestID string
+	Status        string
+	BeforeID      int64 // курсор: id последней задачи предыдущей страницы
+	Limit         int
+}
+
+// CodeHostSyncListResponse страница очереди синхронизации
+type CodeHostSyncListResponse struct {
+	Tasks      []CodeHostSyncTask `json:"tasks"`
+	NextCursor string             `json:"next_cursor,omitempty"`
+}
+
+// EventNotification уведомление о событии PR в очереди отправки
+type EventNotification struct {
+	ID       int64
+	Message  json.RawMessage
+	Attempts int
+}
+
+// SetTeamChatWebhookRequest запрос на задание входящего вебхука чата команды
+type SetTeamChatWebhookRequest struct {
+	TeamName string `json:"team_name"`
+	URL      string `json:"url"` // пустой - уведомления команды идут в общий канал
 }
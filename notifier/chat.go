@@ -0,0 +1,143 @@
+package notifier
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// Форматы сообщений чата
+const (
+	FlavorSlack      = "slack"      // упоминание <@ID>, ник - ID пользователя Slack
+	FlavorMattermost = "mattermost" // упоминание @username
+)
+
+// ChatDirectory настройки чата: адрес входящего вебхука команды и ник пользователя.
+// Пустая строка - настройка не задана
+type ChatDirectory interface {
+	TeamChatWebhookURL(teamName string) (string, error)
+	UserChatHandle(userID string) (string, error)
+}
+
+// ChatNotifier публикует назначения, переназначения, напоминания о сроке ревью и merge
+// во входящий вебхук Slack или Mattermost команды автора PR (или в общий defaultURL).
+// Остальные виды уведомлений пропускаются
+type ChatNotifier struct {
+	directory  ChatDirectory
+	defaultURL string
+	flavor     string
+	client     *http.Client
+}
+
+func NewChatNotifier(directory ChatDirectory, defaultURL, flavor string, client *http.Client) (*ChatNotifier, error) {
+	if flavor == "" {
+		flavor = FlavorSlack
+	}
+	if flavor != FlavorSlack && flavor != FlavorMattermost {
+		return nil, fmt.Errorf("unknown chat flavor %q", flavor)
+	}
+	if client == nil {
+		client = &http.Client{Timeout: 10 * time.Second}
+	}
+	return &ChatNotifier{directory: directory, defaultURL: defaultURL, flavor: flavor, client: client}, nil
+}
+
+// chatPayload тело входящего вебхука, общее для Slack и Mattermost
+type chatPayload struct {
+	Text string `json:"text"`
+}
+
+func (n *ChatNotifier) Notify(ctx context.Context, msg Message) error {
+	text, ok := n.render(msg)
+	if !ok {
+		return nil
+	}
+
+	url := n.defaultURL
+	if msg.TeamName != "" {
+		teamURL, err := n.directory.TeamChatWebhookURL(msg.TeamName)
+		if err != nil {
+			return fmt.Errorf("failed to get chat webhook of team %s: %w", msg.TeamName, err)
+		}
+		if teamURL != "" {
+			url = teamURL
+		}
+	}
+	if url == "" {
+		return nil
+	}
+
+	data, err := json.Marshal(chatPayload{Text: text})
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := n.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to post chat message: %w", err)
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, resp.Body)
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("chat webhook returned status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// render возвращает текст сообщения; false - вид уведомления в чат не публикуется
+func (n *ChatNotifier) render(msg Message) (string, bool) {
+	if len(msg.PullRequests) == 0 {
+		return "", false
+	}
+	pr := msg.PullRequests[0]
+	title := fmt.Sprintf("*%s* (`%s`)", n.escape(pr.PullRequestName), n.escape(pr.PullRequestID))
+
+	switch msg.Kind {
+	case KindAssigned:
+		return fmt.Sprintf(":eyes: %s, you were assigned to review %s by %s",
+			n.mention(msg.UserID), title, n.mention(pr.AuthorID)), true
+	case KindReassigned:
+		return fmt.Sprintf(":arrows_counterclockwise: %s now reviews %s instead of %s",
+			n.mention(msg.UserID), title, n.mention(msg.PreviousUserID)), true
+	case KindReminder:
+		return fmt.Sprintf(":hourglass: %s, %s\n%s", n.mention(msg.UserID), n.escape(msg.Subject), title), true
+	case KindMerged:
+		return fmt.Sprintf(":white_check_mark: %s by %s was merged", title, n.mention(pr.AuthorID)), true
+	}
+	return "", false
+}
+
+// mention возвращает упоминание пользователя; без ника - его user_id без упоминания
+func (n *ChatNotifier) mention(userID string) string {
+	handle, err := n.directory.UserChatHandle(userID)
+	if err != nil {
+		log.Printf("Error getting chat handle of %s: %v", userID, err)
+	}
+	if handle == "" {
+		return n.escape(userID)
+	}
+	if n.flavor == FlavorMattermost {
+		return "@" + handle
+	}
+	return "<@" + handle + ">"
+}
+
+// escape экранирует управляющие символы разметки Slack; Mattermost их не требует
+func (n *ChatNotifier) escape(s string) string {
+	if n.flavor != FlavorSlack {
+		return s
+	}
+	return strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;").Replace(s)
+}
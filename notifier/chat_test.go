@@ -0,0 +1,156 @@
+package notifier
+
+import (
+	"avito/models"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// fakeDirectory настройки чата в памяти
+type fakeDirectory struct {
+	teams   map[string]string
+	handles map[string]string
+}
+
+func (d fakeDirectory) TeamChatWebhookURL(teamName string) (string, error) {
+	return d.teams[teamName], nil
+}
+
+func (d fakeDirectory) UserChatHandle(userID string) (string, error) {
+	return d.handles[userID], nil
+}
+
+// chatServer входящий вебхук чата, запоминающий тексты принятых сообщений
+type chatServer struct {
+	*httptest.Server
+	texts []string
+}
+
+func newChatServer(t *testing.T) *chatServer {
+	s := &chatServer{}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var payload chatPayload
+		if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+			t.Errorf("decode payload: %v", err)
+		}
+		s.texts = append(s.texts, payload.Text)
+	}))
+	t.Cleanup(s.Close)
+	return s
+}
+
+func TestChatNotifierRender(t *testing.T) {
+	pr := models.PullRequestShort{PullRequestID: "pr-1001", PullRequestName: "Fix <b> & escape", AuthorID: "u1"}
+	directory := fakeDirectory{handles: map[string]string{"u1": "alice", "u2": "bob"}}
+
+	tests := []struct {
+		name   string
+		flavor string
+		msg    Message
+		want   string
+	}{
+		{"slack assigned", FlavorSlack,
+			Message{Kind: KindAssigned, UserID: "u2", PullRequests: []models.PullRequestShort{pr}},
+			":eyes: <@bob>, you were assigned to review *Fix &lt;b&gt; &amp; escape* (`pr-1001`) by <@alice>"},
+		{"mattermost assigned", FlavorMattermost,
+			Message{Kind: KindAssigned, UserID: "u2", PullRequests: []models.PullRequestShort{pr}},
+			":eyes: @bob, you were assigned to review *Fix <b> & escape* (`pr-1001`) by @alice"},
+		{"slack reassigned without handle", FlavorSlack,
+			Message{Kind: KindReassigned, UserID: "u3", PreviousUserID: "u2", PullRequests: []models.PullRequestShort{pr}},
+			":arrows_counterclockwise: u3 now reviews *Fix &lt;b&gt; &amp; escape* (`pr-1001`) instead of <@bob>"},
+		{"mattermost merged", FlavorMattermost,
+			Message{Kind: KindMerged, UserID: "u1", PullRequests: []models.PullRequestShort{pr}},
+			":white_check_mark: *Fix <b> & escape* (`pr-1001`) by @alice was merged"},
+		{"slack reminder", FlavorSlack,
+			Message{Kind: KindReminder, UserID: "u2", Subject: "review is due in <1h", PullRequests: []models.PullRequestShort{pr}},
+			":hourglass: <@bob>, review is due in &lt;1h\n*Fix &lt;b&gt; &amp; escape* (`pr-1001`)"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := newChatServer(t)
+			n, err := NewChatNotifier(directory, srv.URL, tt.flavor, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := n.Notify(context.Background(), tt.msg); err != nil {
+				t.Fatalf("Notify: %v", err)
+			}
+			if len(srv.texts) != 1 || srv.texts[0] != tt.want {
+				t.Errorf("posted %q\nwant   %q", srv.texts, tt.want)
+			}
+		})
+	}
+}
+
+func TestChatNotifierSkipsOtherKinds(t *testing.T) {
+	srv := newChatServer(t)
+	n, err := NewChatNotifier(fakeDirectory{}, srv.URL, FlavorSlack, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pr := models.PullRequestShort{PullRequestID: "pr-1001", PullRequestName: "Fix", AuthorID: "u1"}
+	for _, msg := range []Message{
+		{Kind: KindDigest, UserID: "u2", PullRequests: []models.PullRequestShort{pr}},
+		{Kind: KindAssigned, UserID: "u2"},
+	} {
+		if err := n.Notify(context.Background(), msg); err != nil {
+			t.Fatalf("Notify(%s): %v", msg.Kind, err)
+		}
+	}
+	if len(srv.texts) != 0 {
+		t.Errorf("posted %q, want nothing", srv.texts)
+	}
+}
+
+func TestChatNotifierWebhookURL(t *testing.T) {
+	teamSrv := newChatServer(t)
+	defaultSrv := newChatServer(t)
+	directory := fakeDirectory{teams: map[string]string{"backend": teamSrv.URL}}
+	n, err := NewChatNotifier(directory, defaultSrv.URL, FlavorSlack, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	pr := models.PullRequestShort{PullRequestID: "pr-1001", PullRequestName: "Fix", AuthorID: "u1"}
+	for _, team := range []string{"backend", "frontend", ""} {
+		msg := Message{Kind: KindMerged, UserID: "u1", TeamName: team, PullRequests: []models.PullRequestShort{pr}}
+		if err := n.Notify(context.Background(), msg); err != nil {
+			t.Fatalf("Notify(%q): %v", team, err)
+		}
+	}
+	if len(teamSrv.texts) != 1 {
+		t.Errorf("team webhook got %d messages, want 1", len(teamSrv.texts))
+	}
+	if len(defaultSrv.texts) != 2 {
+		t.Errorf("default webhook got %d messages, want 2 (team without webhook and no team)", len(defaultSrv.texts))
+	}
+}
+
+func TestChatNotifierErrorStatus(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "no_service", http.StatusNotFound)
+	}))
+	defer srv.Close()
+
+	n, err := NewChatNotifier(fakeDirectory{}, srv.URL, FlavorMattermost, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	msg := Message{Kind: KindMerged, PullRequests: []models.PullRequestShort{{PullRequestID: "pr-1001", AuthorID: "u1"}}}
+	if err := n.Notify(context.Background(), msg); err == nil {
+		t.Error("expected error for status 404")
+	}
+}
+
+func TestNewChatNotifierFlavor(t *testing.T) {
+	if n, err := NewChatNotifier(fakeDirectory{}, "", "", nil); err != nil || n.flavor != FlavorSlack {
+		t.Errorf("default flavor = %v, %v; want slack", n, err)
+	}
+	if _, err := NewChatNotifier(fakeDirectory{}, "", "teams", nil); err == nil {
+		t.Error("expected error for unknown flavor")
+	}
+}
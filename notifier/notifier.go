@@ -0,0 +1,131 @@
+package notifier
+
+import (
+	"avito/models"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+)
+
+// Виды уведомлений
+const (
+	KindDigest     = "digest"
+	KindReminder   = "reminder"
+	KindAutoClosed = "auto_closed"
+	KindAssigned   = "assigned"
+	KindReassigned = "reassigned"
+	KindMerged     = "merged"
+)
+
+// Message уведомление для пользователя
+type Message struct {
+	Kind           string                    `json:"kind"`
+	UserID         string                    `json:"user_id"`
+	Subject        string                    `json:"subject"`
+	Text           string                    `json:"text"`
+	PullRequests   []models.PullRequestShort `json:"pull_requests,omitempty"`
+	TeamName       string                    `json:"team_name,omitempty"`        // команда автора PR
+	PreviousUserID string                    `json:"previous_user_id,omitempty"` // прежний ревьювер (reassigned)
+	CreatedAt      time.Time                 `json:"created_at"`
+}
+
+// Notifier доставляет уведомления получателю
+type Notifier interface {
+	Notify(ctx context.Context, msg Message) error
+}
+
+// NopNotifier отбрасывает все уведомления
+type NopNotifier struct{}
+
+func (NopNotifier) Notify(ctx context.Context, msg Message) error {
+	return nil
+}
+
+// LogNotifier пишет уведомления построчно в JSON (stdout, файл и т.п.)
+type LogNotifier struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+func NewLogNotifier(w io.Writer) *LogNotifier {
+	return &LogNotifier{w: w}
+}
+
+// NewFileNotifier открывает файл на дозапись и пишет уведомления в него
+func NewFileNotifier(path string) (*LogNotifier, error) {
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open notification file: %w", err)
+	}
+	return NewLogNotifier(f), nil
+}
+
+func (n *LogNotifier) Notify(ctx context.Context, msg Message) error {
+	data, err := json.Marshal(msg)
+	if err != nil {
+		return err
+	}
+
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	_, err = n.w.Write(append(data, '\n'))
+	return err
+}
+
+// HTTPNotifier отправляет уведомления POST-запросом с JSON телом
+type HTTPNotifier struct {
+	url    string
+	client *http.Client
+}
+
+func NewHTTPNotifier(url string, client *http.Client) *HTTPNotifier {
+	if client == nil {
+		client = &http.Client{Timeout: 10 * time.Second}
+	}
+	return &HTTPNotifier{url: url, client: client}
+}
+
+func (n *HTTPNotifier) Notify(ctx context.Context, msg Message) error {
+	data, err := json.Marshal(msg)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, n.url, bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := n.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to send notification: %w", err)
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, resp.Body)
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("notification endpoint returned status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// MultiNotifier отправляет уведомление во все каналы; ошибки каналов объединяются
+type MultiNotifier []Notifier
+
+func (m MultiNotifier) Notify(ctx context.Context, msg Message) error {
+	var errs []error
+	for _, n := range m {
+		if err := n.Notify(ctx, msg); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,117 @@
+package notifier
+
+import (
+	"avito/models"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func testMessage() Message {
+	return Message{
+		Kind:         KindDigest,
+		UserID:       "u2",
+		Subject:      "1 pull request awaits your review",
+		PullRequests: []models.PullRequestShort{{PullRequestID: "pr-1001", PullRequestName: "Fix", AuthorID: "u1", Status: "OPEN"}},
+		CreatedAt:    time.Date(2025, 3, 1, 9, 0, 0, 0, time.UTC),
+	}
+}
+
+func TestHTTPNotifier(t *testing.T) {
+	var (
+		contentType string
+		got         Message
+	)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		contentType = r.Header.Get("Content-Type")
+		if err := json.NewDecoder(r.Body).Decode(&got); err != nil {
+			t.Errorf("decode body: %v", err)
+		}
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer srv.Close()
+
+	msg := testMessage()
+	if err := NewHTTPNotifier(srv.URL, nil).Notify(context.Background(), msg); err != nil {
+		t.Fatalf("Notify: %v", err)
+	}
+	if contentType != "application/json" {
+		t.Errorf("Content-Type = %q", contentType)
+	}
+	if !reflect.DeepEqual(got, msg) {
+		t.Errorf("body = %+v\nwant   %+v", got, msg)
+	}
+}
+
+func TestHTTPNotifierErrorStatus(t *testing.T) {
+	for _, status := range []int{http.StatusBadRequest, http.StatusInternalServerError} {
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(status)
+		}))
+		err := NewHTTPNotifier(srv.URL, nil).Notify(context.Background(), testMessage())
+		srv.Close()
+		if err == nil {
+			t.Errorf("status %d: expected error", status)
+		}
+	}
+}
+
+func TestLogNotifier(t *testing.T) {
+	var buf bytes.Buffer
+	n := NewLogNotifier(&buf)
+	msg := testMessage()
+	for i := 0; i < 2; i++ {
+		if err := n.Notify(context.Background(), msg); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	lines := strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("got %d lines, want 2: %q", len(lines), buf.String())
+	}
+	var got Message
+	if err := json.Unmarshal([]byte(lines[0]), &got); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, msg) {
+		t.Errorf("line = %+v\nwant   %+v", got, msg)
+	}
+}
+
+// recordingNotifier запоминает число вызовов и возвращает err
+type recordingNotifier struct {
+	calls int
+	err   error
+}
+
+func (n *recordingNotifier) Notify(ctx context.Context, msg Message) error {
+	n.calls++
+	return n.err
+}
+
+func TestMultiNotifier(t *testing.T) {
+	errA, errB := errors.New("channel a is down"), errors.New("channel b is down")
+	a, ok, b := &recordingNotifier{err: errA}, &recordingNotifier{}, &recordingNotifier{err: errB}
+
+	err := MultiNotifier{a, ok, b}.Notify(context.Background(), testMessage())
+	for i, n := range []*recordingNotifier{a, ok, b} {
+		if n.calls != 1 {
+			t.Errorf("notifier %d called %d times, want 1", i, n.calls)
+		}
+	}
+	if !errors.Is(err, errA) || !errors.Is(err, errB) {
+		t.Errorf("error = %v, want both channel errors", err)
+	}
+
+	if err := (MultiNotifier{ok}).Notify(context.Background(), testMessage()); err != nil {
+		t.Errorf("error = %v, want nil", err)
+	}
+}
@@ -0,0 +1,90 @@
+package outbox
+
+import (
+	"avito/models"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// Sink получатель событий outbox. Доставка "хотя бы один раз": при ошибке событие
+// будет отправлено повторно, поэтому получатель должен быть идемпотентен по ID события
+type Sink interface {
+	Publish(ctx context.Context, ev models.OutboxEvent) error
+}
+
+// NopSink отбрасывает события
+type NopSink struct{}
+
+func (NopSink) Publish(ctx context.Context, ev models.OutboxEvent) error {
+	return nil
+}
+
+// LogSink пишет события построчно в JSON
+type LogSink struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+func NewLogSink(w io.Writer) *LogSink {
+	return &LogSink{w: w}
+}
+
+func (s *LogSink) Publish(ctx context.Context, ev models.OutboxEvent) error {
+	data, err := json.Marshal(ev)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	_, err = s.w.Write(append(data, '\n'))
+	return err
+}
+
+// HTTPSink отправляет каждое событие POST-запросом с JSON телом; ID события передается
+// в заголовке X-Event-ID для дедупликации на стороне получателя
+type HTTPSink struct {
+	url    string
+	client *http.Client
+}
+
+func NewHTTPSink(url string, client *http.Client) *HTTPSink {
+	if client == nil {
+		client = &http.Client{Timeout: 10 * time.Second}
+	}
+	return &HTTPSink{url: url, client: client}
+}
+
+func (s *HTTPSink) Publish(ctx context.Context, ev models.OutboxEvent) error {
+	data, err := json.Marshal(ev)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.url, bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Event-ID", strconv.FormatInt(ev.ID, 10))
+	req.Header.Set("X-Event-Type", ev.Type)
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to publish event: %w", err)
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, resp.Body)
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("event endpoint returned status %d", resp.StatusCode)
+	}
+	return nil
+}
@@ -0,0 +1,95 @@
+package repository
+
+import (
+	"avito/models"
+	"database/sql"
+	"encoding/json"
+	"time"
+)
+
+// AddAuditEntry записывает изменение сущности; before и after сериализуются в JSON (nil - NULL)
+func (r *Repository) AddAuditEntry(entry *models.AuditEntry, before, after interface{}) error {
+	beforeJSON, err := nullJSON(before)
+	if err != nil {
+		return err
+	}
+	afterJSON, err := nullJSON(after)
+	if err != nil {
+		return err
+	}
+
+	_, err = r.db.Exec(`
+		INSERT INTO audit_log (actor, endpoint, request_id, entity_type, entity_id, before_state, after_state)
+		VALUES ($1, $2, $3, $4, $5, $6::jsonb, $7::jsonb)
+	`, entry.Actor, entry.Endpoint, entry.RequestID, entry.EntityType, entry.EntityID, beforeJSON, afterJSON)
+	return err
+}
+
+func nullJSON(v interface{}) (sql.NullString, error) {
+	if v == nil {
+		return sql.NullString{}, nil
+	}
+	data, err := json.Marshal(v)
+	if err != nil {
+		return sql.NullString{}, err
+	}
+	return sql.NullString{String: string(data), Valid: true}, nil
+}
+
+// ListAuditEntries возвращает записи журнала от новых к старым с keyset-пагинацией по id
+func (r *Repository) ListAuditEntries(filter models.AuditFilter) ([]*models.AuditEntry, error) {
+	w := &whereBuilder{}
+	if filter.Actor != "" {
+		w.add("actor = " + w.arg(filter.Actor))
+	}
+	if filter.EntityType != "" {
+		w.add("entity_type = " + w.arg(filter.EntityType))
+	}
+	if filter.EntityID != "" {
+		w.add("entity_id = " + w.arg(filter.EntityID))
+	}
+	if filter.From != nil {
+		w.add("created_at >= " + w.arg(*filter.From))
+	}
+	if filter.To != nil {
+		w.add("created_at < " + w.arg(*filter.To))
+	}
+	if filter.BeforeID > 0 {
+		w.add("id < " + w.arg(filter.BeforeID))
+	}
+
+	rows, err := r.db.Query(`
+		SELECT id, actor, endpoint, request_id, entity_type, entity_id,
+			COALESCE(before_state, 'null'::jsonb), COALESCE(after_state, 'null'::jsonb), created_at
+		FROM audit_log
+		`+w.sql()+`
+		ORDER BY id DESC
+		LIMIT `+w.arg(filter.Limit), w.args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var entries []*models.AuditEntry
+	for rows.Next() {
+		entry := &models.AuditEntry{}
+		var before, after []byte
+		if err := rows.Scan(&entry.ID, &entry.Actor, &entry.Endpoint, &entry.RequestID, &entry.EntityType, &entry.EntityID,
+			&before, &after, &entry.CreatedAt); err != nil {
+			return nil, err
+		}
+		entry.Before = json.RawMessage(before)
+		entry.After = json.RawMessage(after)
+		entries = append(entries, entry)
+	}
+	return entries, rows.Err()
+}
+
+// DeleteAuditEntriesBefore удаляет записи журнала, созданные раньше before
+func (r *Repository) DeleteAuditEntriesBefore(before time.Time) (int64, error) {
+	res, err := r.db.Exec("DELETE FROM audit_log WHERE created_at < $1", before)
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
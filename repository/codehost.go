@@ -0,0 +1,144 @@
+package repository
+
+import (
+	"avito/models"
+	"database/sql"
+	"sort"
+	"time"
+)
+
+// codeHostSyncLockKey ключ advisory-блокировки: очередь синхронизации обрабатывает один экземпляр
+const codeHostSyncLockKey = 4907
+
+// AddCodeHostSync ставит в очередь запрос или снятие ревьювера во внешней системе
+func (r *Repository) AddCodeHostSync(pullRequestID, action, userID string) error {
+	_, err := r.db.Exec(`
+		INSERT INTO codehost_sync (pull_request_id, action, user_id)
+		VALUES ($1, $2, $3)
+	`, pullRequestID, action, userID)
+	return err
+}
+
+// TryLockCodeHostSync берет блокировку очереди до конца транзакции; false - ее держит другой экземпляр.
+// Под ней задачи только захватываются, запросы к внешней системе идут вне транзакции
+func (r *Repository) TryLockCodeHostSync() (bool, error) {
+	var locked bool
+	err := r.db.QueryRow(`SELECT pg_try_advisory_xact_lock($1)`, codeHostSyncLockKey).Scan(&locked)
+	return locked, err
+}
+
+const codeHostSyncColumnsSQL = `id, pull_request_id, action, user_id, status, attempts, last_error,
+	next_attempt_at, created_at, finished_at`
+
+// codeHostSyncReturningSQL те же колонки для RETURNING в UPDATE с псевдонимом c
+const codeHostSyncReturningSQL = `c.id, c.pull_request_id, c.action, c.user_id, c.status, c.attempts, c.last_error,
+	c.next_attempt_at, c.created_at, c.finished_at`
+
+func scanCodeHostSyncTask(row interface{ Scan(...interface{}) error }) (*models.CodeHostSyncTask, error) {
+	task := &models.CodeHostSyncTask{}
+	var lastError sql.NullString
+	var nextAttemptAt time.Time
+	var finishedAt sql.NullTime
+	if err := row.Scan(&task.ID, &task.PullRequestID, &task.Action, &task.UserID, &task.Status, &task.Attempts,
+		&lastError, &nextAttemptAt, &task.CreatedAt, &finishedAt); err != nil {
+		return nil, err
+	}
+	task.LastError = lastError.String
+	if task.Status == models.SyncPending {
+		task.NextAttemptAt = &nextAttemptAt
+	}
+	if finishedAt.Valid {
+		task.FinishedAt = &finishedAt.Time
+	}
+	return task, nil
+}
+
+// ClaimCodeHostSync захватывает по одной самой ранней ожидающей задаче каждого PR, если для нее
+// наступило время попытки: следующая попытка переносится на leaseUntil, и до этого задача не выдается
+// снова. Следующая задача PR не выдается, пока не завершена предыдущая
+func (r *Repository) ClaimCodeHostSync(now, leaseUntil time.Time, limit int) ([]*models.CodeHostSyncTask, error) {
+	rows, err := r.db.Query(`
+		UPDATE codehost_sync c SET next_attempt_at = $2
+		FROM (
+			SELECT id
+			FROM (
+				SELECT DISTINCT ON (pull_request_id) id, next_attempt_at
+				FROM codehost_sync
+				WHERE status = 'pending'
+				ORDER BY pull_request_id, id
+			) head
+			WHERE next_attempt_at <= $1
+			ORDER BY id
+			LIMIT $3
+		) claimed
+		WHERE c.id = claimed.id
+		RETURNING `+codeHostSyncReturningSQL, now, leaseUntil, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var tasks []*models.CodeHostSyncTask
+	for rows.Next() {
+		task, err := scanCodeHostSyncTask(rows)
+		if err != nil {
+			return nil, err
+		}
+		tasks = append(tasks, task)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	sort.Slice(tasks, func(i, j int) bool { return tasks[i].ID < tasks[j].ID })
+	return tasks, nil
+}
+
+// UpdateCodeHostSync сохраняет результат попытки
+func (r *Repository) UpdateCodeHostSync(task *models.CodeHostSyncTask) error {
+	var nextAttemptAt interface{} = time.Now()
+	if task.NextAttemptAt != nil {
+		nextAttemptAt = *task.NextAttemptAt
+	}
+	_, err := r.db.Exec(`
+		UPDATE codehost_sync
+		SET status = $2, attempts = $3, last_error = NULLIF($4, ''), next_attempt_at = $5, finished_at = $6
+		WHERE id = $1
+	`, task.ID, task.Status, task.Attempts, task.LastError, nextAttemptAt, task.FinishedAt)
+	return err
+}
+
+// ListCodeHostSync возвращает задачи синхронизации от новых к старым с keyset-пагинацией по id
+func (r *Repository) ListCodeHostSync(filter models.CodeHostSyncFilter) ([]*models.CodeHostSyncTask, error) {
+	w := &whereBuilder{}
+	if filter.PullRequestID != "" {
+		w.add("pull_request_id = " + w.arg(filter.PullRequestID))
+	}
+	if filter.Status != "" {
+		w.add("status = " + w.arg(filter.Status))
+	}
+	if filter.BeforeID > 0 {
+		w.add("id < " + w.arg(filter.BeforeID))
+	}
+
+	limit := w.arg(filter.Limit)
+	rows, err := r.db.Query(`
+		SELECT `+codeHostSyncColumnsSQL+`
+		FROM codehost_sync
+		`+w.sql()+`
+		ORDER BY id DESC
+		LIMIT `+limit, w.args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	tasks := []*models.CodeHostSyncTask{}
+	for rows.Next() {
+		task, err := scanCodeHostSyncTask(rows)
+		if err != nil {
+			return nil, err
+		}
+		tasks = append(tasks, task)
+	}
+	return tasks, rows.Err()
+}
@@ -0,0 +1,49 @@
+package repository
+
+import (
+	"avito/models"
+	"encoding/json"
+)
+
+// AddPREvent добавляет событие в историю PR
+func (r *Repository) AddPREvent(pullRequestID, eventType, actor string, payload interface{}) error {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+	if payload == nil {
+		data = []byte("{}")
+	}
+
+	_, err = r.db.Exec(`
+		INSERT INTO pr_events (pull_request_id, event_type, actor, payload)
+		VALUES ($1, $2, $3, $4::jsonb)
+	`, pullRequestID, eventType, actor, string(data))
+	return err
+}
+
+// GetPREvents возвращает историю PR в порядке записи
+func (r *Repository) GetPREvents(pullRequestID string) ([]models.PREvent, error) {
+	rows, err := r.db.Query(`
+		SELECT id, pull_request_id, event_type, actor, payload, created_at
+		FROM pr_events
+		WHERE pull_request_id = $1
+		ORDER BY id
+	`, pullRequestID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	events := []models.PREvent{}
+	for rows.Next() {
+		var ev models.PREvent
+		var payload []byte
+		if err := rows.Scan(&ev.ID, &ev.PullRequestID, &ev.Type, &ev.Actor, &payload, &ev.CreatedAt); err != nil {
+			return nil, err
+		}
+		ev.Payload = json.RawMessage(payload)
+		events = append(events, ev)
+	}
+	return events, rows.Err()
+}
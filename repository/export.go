@@ -0,0 +1,85 @@
+package repository
+
+import (
+	"avito/models"
+	"database/sql"
+
+	"github.com/lib/pq"
+)
+
+// EachPR передает fn PR по фильтрам (без курсора и лимита) от старых к новым.
+// Строки читаются из курсора по одной, выборка целиком в память не загружается
+func (r *Repository) EachPR(filter models.PRFilter, fn func(*models.PRExportRow) error) error {
+	w := prFilterWhere(filter)
+	rows, err := r.db.Query(`
+		SELECT pr.pull_request_id, pr.pull_request_name, pr.author_id, pr.status, pr.priority,
+			COALESCE((SELECT array_agg(l.label ORDER BY l.label) FROM pr_labels l
+				WHERE l.pull_request_id = pr.pull_request_id), '{}'),
+			COALESCE((SELECT array_agg(prr.reviewer_id ORDER BY prr.assigned_at) FROM pr_reviewers prr
+				WHERE prr.pull_request_id = pr.pull_request_id AND prr.role != 'shadow'), '{}'),
+			pr.additions, pr.deletions, pr.files_changed, pr.review_effort, pr.created_at, pr.merged_at, pr.closed_at
+		FROM pull_requests pr
+		`+w.sql()+`
+		ORDER BY pr.created_at, pr.pull_request_id
+	`, w.args...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		row := &models.PRExportRow{}
+		var mergedAt, closedAt sql.NullTime
+		if err := rows.Scan(&row.PullRequestID, &row.PullRequestName, &row.AuthorID, &row.Status, &row.Priority,
+			pq.Array(&row.Labels), pq.Array(&row.AssignedReviewers),
+			&row.Additions, &row.Deletions, &row.FilesChanged, &row.ReviewEffort,
+			&row.CreatedAt, &mergedAt, &closedAt); err != nil {
+			return err
+		}
+		if mergedAt.Valid {
+			row.MergedAt = &mergedAt.Time
+		}
+		if closedAt.Valid {
+			row.ClosedAt = &closedAt.Time
+		}
+		if err := fn(row); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
+
+// EachAssignment передает fn текущие назначения ревьюверов, сделанные в периоде,
+// в порядке назначения. team ограничивает выборку командой ревьювера
+func (r *Repository) EachAssignment(filter models.StatsFilter, fn func(*models.AssignmentExportRow) error) error {
+	rows, err := r.db.Query(`
+		SELECT prr.pull_request_id, pr.author_id, pr.status, prr.reviewer_id, prr.role,
+			prr.assigned_at, COALESCE(prr.verdict, ''), prr.reviewed_at
+		FROM pr_reviewers prr
+		INNER JOIN pull_requests pr ON pr.pull_request_id = prr.pull_request_id
+		WHERE ($1 = '' OR EXISTS (SELECT 1 FROM team_members tm WHERE tm.user_id = prr.reviewer_id AND tm.team_name = $1))
+			AND ($2::timestamp IS NULL OR prr.assigned_at >= $2)
+			AND ($3::timestamp IS NULL OR prr.assigned_at < $3)
+		ORDER BY prr.assigned_at, prr.pull_request_id, prr.reviewer_id
+	`, filter.TeamName, nullTime(filter.From), nullTime(filter.To))
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		row := &models.AssignmentExportRow{}
+		var reviewedAt sql.NullTime
+		if err := rows.Scan(&row.PullRequestID, &row.AuthorID, &row.PRStatus, &row.ReviewerID, &row.Role,
+			&row.AssignedAt, &row.Verdict, &reviewedAt); err != nil {
+			return err
+		}
+		if reviewedAt.Valid {
+			row.ReviewedAt = &reviewedAt.Time
+		}
+		if err := fn(row); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
@@ -0,0 +1,98 @@
+package repository
+
+import (
+	"avito/models"
+	"database/sql"
+)
+
+// SetIdentity привязывает логин внешней системы к пользователю. Пустой external_id
+// не затирает ранее сохраненный
+func (r *Repository) SetIdentity(identity *models.Identity) error {
+	_, err := r.db.Exec(`
+		INSERT INTO user_identities (provider, login, user_id, external_id)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (provider, login) DO UPDATE SET user_id = EXCLUDED.user_id,
+			external_id = COALESCE(NULLIF(EXCLUDED.external_id, ''), user_identities.external_id)
+	`, identity.Provider, identity.Login, identity.UserID, identity.ExternalID)
+	return err
+}
+
+// SetIdentityExternalID запоминает ID внешней системы для привязанного логина
+func (r *Repository) SetIdentityExternalID(provider, login, externalID string) error {
+	_, err := r.db.Exec(`
+		UPDATE user_identities SET external_id = $3
+		WHERE provider = $1 AND login = $2 AND external_id <> $3
+	`, provider, login, externalID)
+	return err
+}
+
+// DeleteIdentity удаляет привязку логина; false - привязки нет
+func (r *Repository) DeleteIdentity(provider, login string) (bool, error) {
+	res, err := r.db.Exec(`DELETE FROM user_identities WHERE provider = $1 AND login = $2`, provider, login)
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	return n > 0, err
+}
+
+// ListIdentities возвращает привязки логинов; пустой provider - всех систем
+func (r *Repository) ListIdentities(provider string) ([]models.Identity, error) {
+	rows, err := r.db.Query(`
+		SELECT provider, login, user_id, external_id
+		FROM user_identities
+		WHERE $1 = '' OR provider = $1
+		ORDER BY provider, login
+	`, provider)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	identities := []models.Identity{}
+	for rows.Next() {
+		var identity models.Identity
+		if err := rows.Scan(&identity.Provider, &identity.Login, &identity.UserID, &identity.ExternalID); err != nil {
+			return nil, err
+		}
+		identities = append(identities, identity)
+	}
+	return identities, rows.Err()
+}
+
+// GetUserIDByLogin возвращает пользователя по логину внешней системы; "" - логин не привязан
+func (r *Repository) GetUserIDByLogin(provider, login string) (string, error) {
+	var userID string
+	err := r.db.QueryRow(`
+		SELECT user_id FROM user_identities WHERE provider = $1 AND login = $2
+	`, provider, login).Scan(&userID)
+	if err == sql.ErrNoRows {
+		return "", nil
+	}
+	return userID, err
+}
+
+// GetUserIDByExternalID возвращает пользователя по ID внешней системы; "" - ID не привязан
+func (r *Repository) GetUserIDByExternalID(provider, externalID string) (string, error) {
+	var userID string
+	err := r.db.QueryRow(`
+		SELECT user_id FROM user_identities WHERE provider = $1 AND external_id = $2 ORDER BY login LIMIT 1
+	`, provider, externalID).Scan(&userID)
+	if err == sql.ErrNoRows {
+		return "", nil
+	}
+	return userID, err
+}
+
+// GetLoginByUserID возвращает логин пользователя во внешней системе; "" - логин не привязан.
+// Если логинов несколько, берется первый по алфавиту
+func (r *Repository) GetLoginByUserID(provider, userID string) (string, error) {
+	var login string
+	err := r.db.QueryRow(`
+		SELECT login FROM user_identities WHERE provider = $1 AND user_id = $2 ORDER BY login LIMIT 1
+	`, provider, userID).Scan(&login)
+	if err == sql.ErrNoRows {
+		return "", nil
+	}
+	return login, err
+}
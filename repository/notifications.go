@@ -0,0 +1,71 @@
+package repository
+
+import (
+	"avito/models"
+	"encoding/json"
+	"sort"
+	"time"
+)
+
+// AddEventNotification ставит уведомление о событии PR в очередь отправки
+func (r *Repository) AddEventNotification(message interface{}) error {
+	data, err := json.Marshal(message)
+	if err != nil {
+		return err
+	}
+	_, err = r.db.Exec(`INSERT INTO event_notifications (message) VALUES ($1::jsonb)`, string(data))
+	return err
+}
+
+// ClaimEventNotifications захватывает уведомления, для которых наступило время попытки: следующая
+// попытка переносится на leaseUntil, и до этого они не выдаются другим экземплярам
+func (r *Repository) ClaimEventNotifications(now, leaseUntil time.Time, limit int) ([]models.EventNotification, error) {
+	rows, err := r.db.Query(`
+		WITH claimed AS (
+			SELECT id FROM event_notifications
+			WHERE next_attempt_at <= $1
+			ORDER BY id
+			LIMIT $3
+			FOR UPDATE SKIP LOCKED
+		)
+		UPDATE event_notifications n SET next_attempt_at = $2
+		FROM claimed
+		WHERE n.id = claimed.id
+		RETURNING n.id, n.message, n.attempts
+	`, now, leaseUntil, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var notifications []models.EventNotification
+	for rows.Next() {
+		var n models.EventNotification
+		var message []byte
+		if err := rows.Scan(&n.ID, &message, &n.Attempts); err != nil {
+			return nil, err
+		}
+		n.Message = json.RawMessage(message)
+		notifications = append(notifications, n)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	sort.Slice(notifications, func(i, j int) bool { return notifications[i].ID < notifications[j].ID })
+	return notifications, nil
+}
+
+// DeleteEventNotification удаляет отправленное (или отброшенное) уведомление из очереди
+func (r *Repository) DeleteEventNotification(id int64) error {
+	_, err := r.db.Exec(`DELETE FROM event_notifications WHERE id = $1`, id)
+	return err
+}
+
+// RetryEventNotification записывает неудачную попытку отправки и время следующей
+func (r *Repository) RetryEventNotification(id int64, lastError string, nextAttemptAt time.Time) error {
+	_, err := r.db.Exec(`
+		UPDATE event_notifications SET attempts = attempts + 1, last_error = $2, next_attempt_at = $3
+		WHERE id = $1
+	`, id, lastError, nextAttemptAt)
+	return err
+}
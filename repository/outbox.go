@@ -0,0 +1,112 @@
+package repository
+
+import (
+	"avito/models"
+	"encoding/json"
+	"sort"
+	"time"
+)
+
+// outboxLockKey ключ advisory-блокировки: пачку outbox обрабатывает один диспетчер
+const outboxLockKey = 4507
+
+// AddOutboxEvent добавляет событие в outbox и возвращает его
+func (r *Repository) AddOutboxEvent(eventType, aggregateType, aggregateID string, payload interface{}) (*models.OutboxEvent, error) {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return nil, err
+	}
+	if payload == nil {
+		data = []byte("{}")
+	}
+
+	ev := &models.OutboxEvent{Type: eventType, AggregateType: aggregateType, AggregateID: aggregateID, Payload: data}
+	err = r.db.QueryRow(`
+		INSERT INTO outbox (event_type, aggregate_type, aggregate_id, payload)
+		VALUES ($1, $2, $3, $4::jsonb)
+		RETURNING id, created_at
+	`, eventType, aggregateType, aggregateID, string(data)).Scan(&ev.ID, &ev.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return ev, nil
+}
+
+// TryLockOutbox берет блокировку outbox до конца транзакции; false - ее держит другой экземпляр.
+// Под ней события только захватываются, сама доставка идет вне транзакции
+func (r *Repository) TryLockOutbox() (bool, error) {
+	var locked bool
+	err := r.db.QueryRow(`SELECT pg_try_advisory_xact_lock($1)`, outboxLockKey).Scan(&locked)
+	return locked, err
+}
+
+// ClaimOutboxEvents захватывает по одному самому раннему недоставленному событию каждого агрегата,
+// если для него наступило время попытки: следующая попытка переносится на leaseUntil, и до этого
+// события не выдаются другим диспетчерам. Если диспетчер не отметил результат (упал), событие
+// снова выдается после leaseUntil. Следующее событие агрегата не выдается, пока не доставлено предыдущее
+func (r *Repository) ClaimOutboxEvents(now, leaseUntil time.Time, limit int) ([]models.OutboxEvent, error) {
+	rows, err := r.db.Query(`
+		UPDATE outbox o SET next_attempt_at = $2
+		FROM (
+			SELECT id
+			FROM (
+				SELECT DISTINCT ON (aggregate_type, aggregate_id) id, next_attempt_at
+				FROM outbox
+				WHERE delivered_at IS NULL
+				ORDER BY aggregate_type, aggregate_id, id
+			) head
+			WHERE next_attempt_at <= $1
+			ORDER BY id
+			LIMIT $3
+		) claimed
+		WHERE o.id = claimed.id
+		RETURNING o.id, o.event_type, o.aggregate_type, o.aggregate_id, o.payload, o.created_at, o.attempts
+	`, now, leaseUntil, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	events := []models.OutboxEvent{}
+	for rows.Next() {
+		var ev models.OutboxEvent
+		var payload []byte
+		if err := rows.Scan(&ev.ID, &ev.Type, &ev.AggregateType, &ev.AggregateID, &payload, &ev.CreatedAt, &ev.Attempts); err != nil {
+			return nil, err
+		}
+		ev.Payload = json.RawMessage(payload)
+		events = append(events, ev)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	sort.Slice(events, func(i, j int) bool { return events[i].ID < events[j].ID })
+	return events, nil
+}
+
+// MarkOutboxDelivered отмечает событие доставленным
+func (r *Repository) MarkOutboxDelivered(id int64, deliveredAt time.Time) error {
+	_, err := r.db.Exec(`
+		UPDATE outbox SET delivered_at = $2, attempts = attempts + 1, last_error = NULL
+		WHERE id = $1
+	`, id, deliveredAt)
+	return err
+}
+
+// MarkOutboxFailed записывает неудачную попытку доставки и время следующей
+func (r *Repository) MarkOutboxFailed(id int64, lastError string, nextAttemptAt time.Time) error {
+	_, err := r.db.Exec(`
+		UPDATE outbox SET attempts = attempts + 1, last_error = $2, next_attempt_at = $3
+		WHERE id = $1
+	`, id, lastError, nextAttemptAt)
+	return err
+}
+
+// DeleteDeliveredOutboxEvents удаляет доставленные события, доставленные раньше before
+func (r *Repository) DeleteDeliveredOutboxEvents(before time.Time) (int64, error) {
+	res, err := r.db.Exec(`DELETE FROM outbox WHERE delivered_at IS NOT NULL AND delivered_at < $1`, before)
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
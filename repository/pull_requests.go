@@ -0,0 +1,276 @@
+package repository
+
+import (
+	"avito/models"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// whereBuilder собирает условия WHERE с позиционными параметрами $1, $2, ...
+type whereBuilder struct {
+	conds []string
+	args  []interface{}
+}
+
+// arg добавляет параметр и возвращает его плейсхолдер
+func (w *whereBuilder) arg(v interface{}) string {
+	w.args = append(w.args, v)
+	return fmt.Sprintf("$%d", len(w.args))
+}
+
+func (w *whereBuilder) add(cond string) {
+	w.conds = append(w.conds, cond)
+}
+
+func (w *whereBuilder) sql() string {
+	if len(w.conds) == 0 {
+		return ""
+	}
+	return "WHERE " + strings.Join(w.conds, " AND ")
+}
+
+// prColumnsSQL колонки PR в порядке scanPRRow
+const prColumnsSQL = `pr.pull_request_id, pr.pull_request_name, pr.author_id, pr.status, pr.priority,
+	pr.additions, pr.deletions, pr.files_changed, pr.review_effort, pr.created_at, pr.merged_at, pr.closed_at,
+	COALESCE((SELECT array_agg(l.label ORDER BY l.label) FROM pr_labels l
+		WHERE l.pull_request_id = pr.pull_request_id), '{}')`
+
+// lastActivitySQL время последней активности по PR: создание, назначение ревьювера или вердикт
+const lastActivitySQL = `(SELECT GREATEST(pr.created_at, MAX(a.assigned_at), MAX(a.reviewed_at))
+	FROM pr_reviewers a WHERE a.pull_request_id = pr.pull_request_id)`
+
+// ListPRs возвращает PR по фильтрам от новых к старым с keyset-пагинацией
+func (r *Repository) ListPRs(filter models.PRFilter) ([]*models.PullRequest, error) {
+	w := prFilterWhere(filter)
+	if filter.AfterCreatedAt != nil {
+		w.add(fmt.Sprintf("(pr.created_at, pr.pull_request_id) < (%s, %s)",
+			w.arg(*filter.AfterCreatedAt), w.arg(filter.AfterID)))
+	}
+
+	rows, err := r.db.Query(`
+		SELECT `+prColumnsSQL+`
+		FROM pull_requests pr
+		`+w.sql()+`
+		ORDER BY pr.created_at DESC, pr.pull_request_id DESC
+		LIMIT `+w.arg(filter.Limit), w.args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var prs []*models.PullRequest
+	for rows.Next() {
+		pr, err := scanPRRow(rows)
+		if err != nil {
+			return nil, err
+		}
+		prs = append(prs, pr)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	rows.Close()
+
+	return prs, r.attachReviewers(prs)
+}
+
+// prFilterWhere переводит фильтры PR (кроме курсора и лимита) в условия WHERE
+func prFilterWhere(filter models.PRFilter) *whereBuilder {
+	w := &whereBuilder{}
+	if filter.Status != "" {
+		w.add("pr.status = " + w.arg(filter.Status))
+	}
+	if filter.AuthorID != "" {
+		w.add("pr.author_id = " + w.arg(filter.AuthorID))
+	}
+	if filter.ReviewerID != "" {
+		w.add("EXISTS (SELECT 1 FROM pr_reviewers prr WHERE prr.pull_request_id = pr.pull_request_id AND prr.reviewer_id = " +
+			w.arg(filter.ReviewerID) + ")")
+	}
+	if filter.TeamName != "" {
+		w.add("EXISTS (SELECT 1 FROM team_members tm WHERE tm.user_id = pr.author_id AND tm.team_name = " +
+			w.arg(filter.TeamName) + ")")
+	}
+	if filter.CreatedFrom != nil {
+		w.add("pr.created_at >= " + w.arg(*filter.CreatedFrom))
+	}
+	if filter.CreatedTo != nil {
+		w.add("pr.created_at < " + w.arg(*filter.CreatedTo))
+	}
+	if filter.MergedFrom != nil {
+		w.add("pr.merged_at >= " + w.arg(*filter.MergedFrom))
+	}
+	if filter.MergedTo != nil {
+		w.add("pr.merged_at < " + w.arg(*filter.MergedTo))
+	}
+	if len(filter.Labels) > 0 {
+		labels := w.arg(pq.Array(filter.Labels))
+		w.add(fmt.Sprintf(`pr.pull_request_id IN (
+			SELECT l.pull_request_id FROM pr_labels l
+			WHERE l.label = ANY(%s)
+			GROUP BY l.pull_request_id
+			HAVING COUNT(DISTINCT l.label) = cardinality(%s::text[]))`, labels, labels))
+	}
+	return w
+}
+
+// scanPRRow читает PR из строки с колонками prColumnsSQL; dest - дополнительные колонки после меток
+func scanPRRow(rows *sql.Rows, dest ...interface{}) (*models.PullRequest, error) {
+	pr := &models.PullRequest{Labels: []string{}}
+	var createdAt, mergedAt, closedAt sql.NullTime
+	cols := []interface{}{&pr.PullRequestID, &pr.PullRequestName, &pr.AuthorID, &pr.Status, &pr.Priority,
+		&pr.Additions, &pr.Deletions, &pr.FilesChanged, &pr.ReviewEffort, &createdAt, &mergedAt, &closedAt,
+		pq.Array(&pr.Labels)}
+	if err := rows.Scan(append(cols, dest...)...); err != nil {
+		return nil, err
+	}
+	if createdAt.Valid {
+		pr.CreatedAt = &createdAt.Time
+	}
+	if mergedAt.Valid {
+		pr.MergedAt = &mergedAt.Time
+	}
+	if closedAt.Valid {
+		pr.ClosedAt = &closedAt.Time
+	}
+	return pr, nil
+}
+
+// attachReviewers загружает ревьюверов для списка PR одним запросом
+func (r *Repository) attachReviewers(prs []*models.PullRequest) error {
+	if len(prs) == 0 {
+		return nil
+	}
+
+	byID := make(map[string]*models.PullRequest, len(prs))
+	ids := make([]string, len(prs))
+	for i, pr := range prs {
+		ids[i] = pr.PullRequestID
+		byID[pr.PullRequestID] = pr
+	}
+	reviewerRows, err := r.db.Query(`
+		SELECT pull_request_id, reviewer_id, role, assigned_at, COALESCE(verdict, ''), reviewed_at
+		FROM pr_reviewers
+		WHERE pull_request_id = ANY($1)
+		ORDER BY pull_request_id, CASE role WHEN 'required' THEN 0 WHEN 'optional' THEN 1 ELSE 2 END, assigned_at
+	`, pq.Array(ids))
+	if err != nil {
+		return err
+	}
+	defer reviewerRows.Close()
+
+	for reviewerRows.Next() {
+		var prID string
+		reviewer, err := scanPRReviewer(reviewerRows, &prID)
+		if err != nil {
+			return err
+		}
+		pr := byID[prID]
+		pr.Reviewers = append(pr.Reviewers, reviewer)
+		if reviewer.Role != models.RoleShadow {
+			pr.AssignedReviewers = append(pr.AssignedReviewers, reviewer.UserID)
+		}
+	}
+	return reviewerRows.Err()
+}
+
+// GetStalePRs возвращает OPEN PR, созданные раньше createdBefore, от старых к новым.
+// teamName ограничивает выборку командой автора
+func (r *Repository) GetStalePRs(createdBefore time.Time, teamName string) ([]*models.StalePR, error) {
+	rows, err := r.db.Query(`
+		SELECT `+prColumnsSQL+`, `+lastActivitySQL+`
+		FROM pull_requests pr
+		WHERE pr.status = 'OPEN' AND pr.created_at < $1
+			AND ($2 = '' OR EXISTS (SELECT 1 FROM team_members tm WHERE tm.user_id = pr.author_id AND tm.team_name = $2))
+		ORDER BY pr.created_at, pr.pull_request_id
+	`, createdBefore, teamName)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	stale := []*models.StalePR{}
+	var prs []*models.PullRequest
+	for rows.Next() {
+		var lastActivity time.Time
+		pr, err := scanPRRow(rows, &lastActivity)
+		if err != nil {
+			return nil, err
+		}
+		prs = append(prs, pr)
+		stale = append(stale, &models.StalePR{LastActivityAt: lastActivity})
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	rows.Close()
+
+	if err := r.attachReviewers(prs); err != nil {
+		return nil, err
+	}
+	for i, pr := range prs {
+		stale[i].PullRequest = *pr
+	}
+	return stale, nil
+}
+
+// GetPRsToAutoClose возвращает OPEN PR без активности дольше auto_close_after_days
+// любой из команд автора
+func (r *Repository) GetPRsToAutoClose(now time.Time) ([]models.PullRequestShort, error) {
+	rows, err := r.db.Query(`
+		SELECT pr.pull_request_id, pr.pull_request_name, pr.author_id, pr.status, pr.priority
+		FROM pull_requests pr
+		WHERE pr.status = 'OPEN' AND EXISTS (
+			SELECT 1 FROM team_members tm
+			INNER JOIN teams t ON t.team_name = tm.team_name
+			WHERE tm.user_id = pr.author_id AND t.auto_close_after_days > 0
+				AND `+lastActivitySQL+` < $1::timestamp - make_interval(days => t.auto_close_after_days))
+		ORDER BY pr.created_at, pr.pull_request_id
+	`, now)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var prs []models.PullRequestShort
+	for rows.Next() {
+		var pr models.PullRequestShort
+		if err := rows.Scan(&pr.PullRequestID, &pr.PullRequestName, &pr.AuthorID, &pr.Status, &pr.Priority); err != nil {
+			return nil, err
+		}
+		prs = append(prs, pr)
+	}
+	return prs, rows.Err()
+}
+
+// ClosePR закрывает OPEN PR без merge; возвращает false, если PR уже не открыт
+func (r *Repository) ClosePR(pullRequestID string, closedAt time.Time) (bool, error) {
+	res, err := r.db.Exec(`
+		UPDATE pull_requests
+		SET status = 'CLOSED', closed_at = $1
+		WHERE pull_request_id = $2 AND status = 'OPEN'
+	`, closedAt, pullRequestID)
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	return n > 0, err
+}
+
+// ReopenPR возвращает CLOSED PR в OPEN; false - PR не в статусе CLOSED
+func (r *Repository) ReopenPR(pullRequestID string) (bool, error) {
+	res, err := r.db.Exec(`
+		UPDATE pull_requests
+		SET status = 'OPEN', closed_at = NULL
+		WHERE pull_request_id = $1 AND status = 'CLOSED'
+	`, pullRequestID)
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	return n > 0, err
+}
@@ -5,15 +5,46 @@ import (
 	"database/sql"
 	"fmt"
 	"math/rand"
+	"sort"
+	"strings"
 	"time"
+
+	"github.com/lib/pq"
 )
 
+// dbtx общий интерфейс *sql.DB и *sql.Tx
+type dbtx interface {
+	Exec(query string, args ...interface{}) (sql.Result, error)
+	Query(query string, args ...interface{}) (*sql.Rows, error)
+	QueryRow(query string, args ...interface{}) *sql.Row
+}
+
 type Repository struct {
-	db *sql.DB
+	conn *sql.DB
+	db   dbtx
 }
 
 func NewRepository(db *sql.DB) *Repository {
-	return &Repository{db: db}
+	return &Repository{conn: db, db: db}
+}
+
+// InTx выполняет fn в транзакции. Репозиторий, переданный в fn, работает внутри нее;
+// вложенные вызовы InTx используют уже открытую транзакцию
+func (r *Repository) InTx(fn func(tx *Repository) error) error {
+	if _, ok := r.db.(*sql.Tx); ok {
+		return fn(r)
+	}
+
+	tx, err := r.conn.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := fn(&Repository{conn: r.conn, db: tx}); err != nil {
+		return err
+	}
+	return tx.Commit()
 }
 
 // User methods
@@ -29,17 +60,21 @@ func (r *Repository) CreateOrUpdateUser(userID, username string, isActive bool)
 
 func (r *Repository) GetUser(userID string) (*models.User, error) {
 	user := &models.User{}
+	var departedAt sql.NullTime
 	err := r.db.QueryRow(`
-		SELECT u.user_id, u.username, u.is_active
+		SELECT u.user_id, u.username, u.is_active, u.is_learning_reviewer, u.is_senior, u.departed_at
 		FROM users u
 		WHERE u.user_id = $1
-	`, userID).Scan(&user.UserID, &user.Username, &user.IsActive)
+	`, userID).Scan(&user.UserID, &user.Username, &user.IsActive, &user.IsLearningReviewer, &user.IsSenior, &departedAt)
 	if err == sql.ErrNoRows {
 		return nil, fmt.Errorf("user not found")
 	}
 	if err != nil {
 		return nil, err
 	}
+	if departedAt.Valid {
+		user.DepartedAt = &departedAt.Time
+	}
 
 	// Получаем team_name пользователя (берем первую команду)
 	err = r.db.QueryRow(`
@@ -55,6 +90,101 @@ func (r *Repository) GetUser(userID string) (*models.User, error) {
 	return user, nil
 }
 
+// ListUsers возвращает пользователей с командами, навыками и нагрузкой, упорядоченных по user_id.
+// Limit 0 - без ограничения
+func (r *Repository) ListUsers(filter models.UserFilter) ([]*models.User, error) {
+	var isActive sql.NullBool
+	if filter.IsActive != nil {
+		isActive = sql.NullBool{Bool: *filter.IsActive, Valid: true}
+	}
+
+	rows, err := r.db.Query(`
+		SELECT u.user_id, u.username, u.is_active, u.is_learning_reviewer, u.is_senior, u.departed_at,
+			COALESCE((SELECT array_agg(tm.team_name ORDER BY tm.team_name) FROM team_members tm
+				WHERE tm.user_id = u.user_id), '{}'),
+			COALESCE((SELECT array_agg(us.skill ORDER BY us.skill) FROM user_skills us
+				WHERE us.user_id = u.user_id), '{}'),
+			COALESCE(load.open_reviews, 0), COALESCE(load.review_load, 0)
+		FROM users u
+		LEFT JOIN (
+			SELECT prr.reviewer_id,
+				COUNT(*) AS open_reviews,
+				SUM(CASE WHEN prr.role != 'shadow' THEN pr.review_effort ELSE 0 END) AS review_load
+			FROM pr_reviewers prr
+			INNER JOIN pull_requests pr ON pr.pull_request_id = prr.pull_request_id
+			WHERE pr.status = 'OPEN'
+			GROUP BY prr.reviewer_id
+		) load ON load.reviewer_id = u.user_id
+		WHERE ($1 = '' OR EXISTS (SELECT 1 FROM team_members tm WHERE tm.user_id = u.user_id AND tm.team_name = $1))
+			AND ($2::boolean IS NULL OR u.is_active = $2)
+			AND ($3 = '' OR EXISTS (SELECT 1 FROM user_skills us WHERE us.user_id = u.user_id AND us.skill = $3))
+			AND ($4 = '' OR lower(u.username) LIKE lower($4) || '%' ESCAPE '\')
+			AND ($5 = '' OR u.user_id > $5)
+			AND ($6 = '' OR u.user_id = $6)
+		ORDER BY u.user_id
+		LIMIT NULLIF($7, 0)
+	`, filter.TeamName, isActive, filter.Skill, escapeLike(filter.UsernamePrefix), filter.AfterUserID, filter.UserID, filter.Limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var users []*models.User
+	for rows.Next() {
+		user := &models.User{}
+		var departedAt sql.NullTime
+		if err := rows.Scan(&user.UserID, &user.Username, &user.IsActive, &user.IsLearningReviewer, &user.IsSenior, &departedAt,
+			pq.Array(&user.Teams), pq.Array(&user.Skills), &user.OpenReviews, &user.ReviewLoad); err != nil {
+			return nil, err
+		}
+		if departedAt.Valid {
+			user.DepartedAt = &departedAt.Time
+		}
+		if len(user.Teams) > 0 {
+			user.TeamName = user.Teams[0]
+		}
+		users = append(users, user)
+	}
+	return users, rows.Err()
+}
+
+// GetUserDetails возвращает пользователя со всеми командами, навыками и текущей нагрузкой
+func (r *Repository) GetUserDetails(userID string) (*models.User, error) {
+	users, err := r.ListUsers(models.UserFilter{UserID: userID, Limit: 1})
+	if err != nil {
+		return nil, err
+	}
+	if len(users) == 0 {
+		return nil, fmt.Errorf("user not found")
+	}
+	return users[0], nil
+}
+
+func (r *Repository) SetUserSkills(userID string, skills []string) error {
+	if _, err := r.db.Exec("DELETE FROM user_skills WHERE user_id = $1", userID); err != nil {
+		return err
+	}
+	for _, skill := range skills {
+		if _, err := r.db.Exec("INSERT INTO user_skills (user_id, skill) VALUES ($1, $2) ON CONFLICT DO NOTHING",
+			userID, skill); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (r *Repository) UpdateReviewerProfile(userID string, isLearningReviewer, isSenior bool) error {
+	_, err := r.db.Exec("UPDATE users SET is_learning_reviewer = $1, is_senior = $2 WHERE user_id = $3",
+		isLearningReviewer, isSenior, userID)
+	return err
+}
+
+// MarkUserDeparted помечает пользователя уволенным и неактивным
+func (r *Repository) MarkUserDeparted(userID string, departedAt time.Time) error {
+	_, err := r.db.Exec("UPDATE users SET is_active = false, departed_at = $1 WHERE user_id = $2", departedAt, userID)
+	return err
+}
+
 func (r *Repository) UpdateUserActivity(userID string, isActive bool) error {
 	_, err := r.db.Exec("UPDATE users SET is_active = $1 WHERE user_id = $2", isActive, userID)
 	return err
@@ -82,9 +212,21 @@ func (r *Repository) GetTeam(teamName string) (*models.Team, error) {
 		return nil, fmt.Errorf("team not found")
 	}
 
+	policy, err := r.GetTeamPolicy(teamName)
+	if err != nil {
+		return nil, err
+	}
+
+	parent, err := r.GetTeamParent(teamName)
+	if err != nil {
+		return nil, err
+	}
+
 	team := &models.Team{
-		TeamName: teamName,
-		Members:  []models.TeamMember{},
+		TeamName:       teamName,
+		ParentTeamName: parent,
+		Members:        []models.TeamMember{},
+		Policy:         policy,
 	}
 
 	// Получаем участников команды
@@ -110,12 +252,117 @@ func (r *Repository) GetTeam(teamName string) (*models.Team, error) {
 	return team, rows.Err()
 }
 
+func (r *Repository) GetTeamPolicy(teamName string) (*models.TeamPolicy, error) {
+	policy := &models.TeamPolicy{}
+	err := r.db.QueryRow(`
+		SELECT large_pr_effort_threshold, large_pr_extra_reviewers, auto_close_after_days
+		FROM teams
+		WHERE team_name = $1
+	`, teamName).Scan(&policy.LargePREffortThreshold, &policy.LargePRExtraReviewers, &policy.AutoCloseAfterDays)
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("team not found")
+	}
+	return policy, err
+}
+
+func (r *Repository) UpdateTeamPolicy(teamName string, policy *models.TeamPolicy) error {
+	_, err := r.db.Exec(`
+		UPDATE teams
+		SET large_pr_effort_threshold = $1, large_pr_extra_reviewers = $2, auto_close_after_days = $3
+		WHERE team_name = $4
+	`, policy.LargePREffortThreshold, policy.LargePRExtraReviewers, policy.AutoCloseAfterDays, teamName)
+	return err
+}
+
+// RenameTeam переименовывает команду; участники и дочерние команды обновляются каскадно
+func (r *Repository) RenameTeam(teamName, newTeamName string) error {
+	_, err := r.db.Exec("UPDATE teams SET team_name = $1 WHERE team_name = $2", newTeamName, teamName)
+	return err
+}
+
+// DeleteTeam удаляет команду; дочерние команды переходят к ее родителю
+func (r *Repository) DeleteTeam(teamName string) error {
+	_, err := r.db.Exec(`
+		UPDATE teams
+		SET parent_team_name = (SELECT parent_team_name FROM teams WHERE team_name = $1)
+		WHERE parent_team_name = $1
+	`, teamName)
+	if err != nil {
+		return err
+	}
+
+	_, err = r.db.Exec("DELETE FROM teams WHERE team_name = $1", teamName)
+	return err
+}
+
+// GetTeamParent возвращает имя родительской команды или пустую строку для корневой
+func (r *Repository) GetTeamParent(teamName string) (string, error) {
+	var parent sql.NullString
+	err := r.db.QueryRow("SELECT parent_team_name FROM teams WHERE team_name = $1", teamName).Scan(&parent)
+	if err == sql.ErrNoRows {
+		return "", fmt.Errorf("team not found")
+	}
+	return parent.String, err
+}
+
+// SetTeamParent задает родительскую команду (пустая строка делает команду корневой)
+func (r *Repository) SetTeamParent(teamName, parentTeamName string) error {
+	var parent sql.NullString
+	if parentTeamName != "" {
+		parent = sql.NullString{String: parentTeamName, Valid: true}
+	}
+	_, err := r.db.Exec("UPDATE teams SET parent_team_name = $1 WHERE team_name = $2", parent, teamName)
+	return err
+}
+
+// ListTeams возвращает все команды с родителем и количеством участников
+func (r *Repository) ListTeams() ([]*models.TeamSummary, error) {
+	rows, err := r.db.Query(`
+		SELECT t.team_name, COALESCE(t.parent_team_name, ''), COUNT(tm.user_id)
+		FROM teams t
+		LEFT JOIN team_members tm ON tm.team_name = t.team_name
+		GROUP BY t.team_name, t.parent_team_name
+		ORDER BY t.team_name
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var teams []*models.TeamSummary
+	for rows.Next() {
+		team := &models.TeamSummary{}
+		if err := rows.Scan(&team.TeamName, &team.ParentTeamName, &team.MembersCount); err != nil {
+			return nil, err
+		}
+		teams = append(teams, team)
+	}
+	return teams, rows.Err()
+}
+
 func (r *Repository) AddUserToTeam(teamName, userID string) error {
 	_, err := r.db.Exec("INSERT INTO team_members (team_name, user_id) VALUES ($1, $2) ON CONFLICT DO NOTHING",
 		teamName, userID)
 	return err
 }
 
+func (r *Repository) RemoveUserFromTeam(teamName, userID string) error {
+	_, err := r.db.Exec("DELETE FROM team_members WHERE team_name = $1 AND user_id = $2", teamName, userID)
+	return err
+}
+
+func (r *Repository) RemoveUserFromAllTeams(userID string) error {
+	_, err := r.db.Exec("DELETE FROM team_members WHERE user_id = $1", userID)
+	return err
+}
+
+func (r *Repository) IsTeamMember(teamName, userID string) (bool, error) {
+	var exists bool
+	err := r.db.QueryRow("SELECT EXISTS(SELECT 1 FROM team_members WHERE team_name = $1 AND user_id = $2)",
+		teamName, userID).Scan(&exists)
+	return exists, err
+}
+
 func (r *Repository) GetUserTeamName(userID string) (string, error) {
 	var teamName string
 	err := r.db.QueryRow(`
@@ -130,12 +377,33 @@ func (r *Repository) GetUserTeamName(userID string) (string, error) {
 	return teamName, err
 }
 
+// GetUserTeamNames возвращает все команды пользователя по алфавиту
+func (r *Repository) GetUserTeamNames(userID string) ([]string, error) {
+	rows, err := r.db.Query(`
+		SELECT team_name FROM team_members WHERE user_id = $1 ORDER BY team_name
+	`, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var teamNames []string
+	for rows.Next() {
+		var teamName string
+		if err := rows.Scan(&teamName); err != nil {
+			return nil, err
+		}
+		teamNames = append(teamNames, teamName)
+	}
+	return teamNames, rows.Err()
+}
+
 func (r *Repository) GetActiveTeamMembersExcept(teamName, excludeUserID string) ([]*models.User, error) {
 	rows, err := r.db.Query(`
-		SELECT u.user_id, u.username, u.is_active
+		SELECT u.user_id, u.username, u.is_active, u.is_learning_reviewer, u.is_senior
 		FROM users u
 		INNER JOIN team_members tm ON u.user_id = tm.user_id
-		WHERE tm.team_name = $1 AND u.is_active = true AND u.user_id != $2
+		WHERE tm.team_name = $1 AND u.is_active = true AND u.departed_at IS NULL AND u.user_id != $2
 	`, teamName, excludeUserID)
 	if err != nil {
 		return nil, err
@@ -145,7 +413,7 @@ func (r *Repository) GetActiveTeamMembersExcept(teamName, excludeUserID string)
 	var users []*models.User
 	for rows.Next() {
 		user := &models.User{}
-		if err := rows.Scan(&user.UserID, &user.Username, &user.IsActive); err != nil {
+		if err := rows.Scan(&user.UserID, &user.Username, &user.IsActive, &user.IsLearningReviewer, &user.IsSenior); err != nil {
 			return nil, err
 		}
 		user.TeamName = teamName
@@ -156,10 +424,10 @@ func (r *Repository) GetActiveTeamMembersExcept(teamName, excludeUserID string)
 
 func (r *Repository) GetActiveTeamMembers(teamName string) ([]*models.User, error) {
 	rows, err := r.db.Query(`
-		SELECT u.user_id, u.username, u.is_active
+		SELECT u.user_id, u.username, u.is_active, u.is_learning_reviewer, u.is_senior
 		FROM users u
 		INNER JOIN team_members tm ON u.user_id = tm.user_id
-		WHERE tm.team_name = $1 AND u.is_active = true
+		WHERE tm.team_name = $1 AND u.is_active = true AND u.departed_at IS NULL
 	`, teamName)
 	if err != nil {
 		return nil, err
@@ -169,7 +437,7 @@ func (r *Repository) GetActiveTeamMembers(teamName string) ([]*models.User, erro
 	var users []*models.User
 	for rows.Next() {
 		user := &models.User{}
-		if err := rows.Scan(&user.UserID, &user.Username, &user.IsActive); err != nil {
+		if err := rows.Scan(&user.UserID, &user.Username, &user.IsActive, &user.IsLearningReviewer, &user.IsSenior); err != nil {
 			return nil, err
 		}
 		user.TeamName = teamName
@@ -186,48 +454,59 @@ func (r *Repository) PRExists(pullRequestID string) (bool, error) {
 }
 
 func (r *Repository) CreatePR(pr *models.PullRequest) error {
-	tx, err := r.db.Begin()
-	if err != nil {
-		return err
-	}
-	defer tx.Rollback()
-
-	var createdAt time.Time
-	if pr.CreatedAt != nil {
-		createdAt = *pr.CreatedAt
-	} else {
-		createdAt = time.Now()
-	}
-
-	_, err = tx.Exec(`
-		INSERT INTO pull_requests (pull_request_id, pull_request_name, author_id, status, created_at)
-		VALUES ($1, $2, $3, $4, $5)
-	`, pr.PullRequestID, pr.PullRequestName, pr.AuthorID, pr.Status, createdAt)
-	if err != nil {
-		return err
-	}
+	return r.InTx(func(tx *Repository) error {
+		var createdAt time.Time
+		if pr.CreatedAt != nil {
+			createdAt = *pr.CreatedAt
+		} else {
+			createdAt = time.Now()
+		}
 
-	for _, reviewerID := range pr.AssignedReviewers {
-		_, err = tx.Exec("INSERT INTO pr_reviewers (pull_request_id, reviewer_id) VALUES ($1, $2)",
-			pr.PullRequestID, reviewerID)
+		_, err := tx.db.Exec(`
+			INSERT INTO pull_requests (pull_request_id, pull_request_name, author_id, status, priority,
+				additions, deletions, files_changed, review_effort, created_at)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+		`, pr.PullRequestID, pr.PullRequestName, pr.AuthorID, pr.Status, pr.Priority,
+			pr.Additions, pr.Deletions, pr.FilesChanged, pr.ReviewEffort, createdAt)
 		if err != nil {
 			return err
 		}
-	}
 
-	return tx.Commit()
+		for _, label := range pr.Labels {
+			_, err = tx.db.Exec("INSERT INTO pr_labels (pull_request_id, label) VALUES ($1, $2) ON CONFLICT DO NOTHING",
+				pr.PullRequestID, label)
+			if err != nil {
+				return err
+			}
+		}
+
+		for _, reviewer := range pr.Reviewers {
+			_, err = tx.db.Exec("INSERT INTO pr_reviewers (pull_request_id, reviewer_id, role) VALUES ($1, $2, $3)",
+				pr.PullRequestID, reviewer.UserID, reviewer.Role)
+			if err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
 }
 
 func (r *Repository) GetPR(pullRequestID string) (*models.PullRequest, error) {
-	pr := &models.PullRequest{}
+	pr := &models.PullRequest{Labels: []string{}}
 	var createdAt sql.NullTime
 	var mergedAt sql.NullTime
+	var closedAt sql.NullTime
 
 	err := r.db.QueryRow(`
-		SELECT pull_request_id, pull_request_name, author_id, status, created_at, merged_at
+		SELECT pull_request_id, pull_request_name, author_id, status, priority,
+			additions, deletions, files_changed, review_effort, created_at, merged_at, closed_at,
+			COALESCE((SELECT array_agg(label ORDER BY label) FROM pr_labels WHERE pull_request_id = $1), '{}')
 		FROM pull_requests
 		WHERE pull_request_id = $1
-	`, pullRequestID).Scan(&pr.PullRequestID, &pr.PullRequestName, &pr.AuthorID, &pr.Status, &createdAt, &mergedAt)
+	`, pullRequestID).Scan(&pr.PullRequestID, &pr.PullRequestName, &pr.AuthorID, &pr.Status, &pr.Priority,
+		&pr.Additions, &pr.Deletions, &pr.FilesChanged, &pr.ReviewEffort,
+		&createdAt, &mergedAt, &closedAt, pq.Array(&pr.Labels))
 	if err == sql.ErrNoRows {
 		return nil, fmt.Errorf("PR not found")
 	}
@@ -241,20 +520,31 @@ func (r *Repository) GetPR(pullRequestID string) (*models.PullRequest, error) {
 	if mergedAt.Valid {
 		pr.MergedAt = &mergedAt.Time
 	}
+	if closedAt.Valid {
+		pr.ClosedAt = &closedAt.Time
+	}
 
-	// Получаем ревьюверов
-	rows, err := r.db.Query("SELECT reviewer_id FROM pr_reviewers WHERE pull_request_id = $1", pullRequestID)
+	// Получаем ревьюверов: сначала обязательные, затем дополнительные и shadow
+	rows, err := r.db.Query(`
+		SELECT reviewer_id, role, assigned_at, COALESCE(verdict, ''), reviewed_at
+		FROM pr_reviewers
+		WHERE pull_request_id = $1
+		ORDER BY CASE role WHEN 'required' THEN 0 WHEN 'optional' THEN 1 ELSE 2 END, assigned_at
+	`, pullRequestID)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
 
 	for rows.Next() {
-		var reviewerID string
-		if err := rows.Scan(&reviewerID); err != nil {
+		reviewer, err := scanPRReviewer(rows)
+		if err != nil {
 			return nil, err
 		}
-		pr.AssignedReviewers = append(pr.AssignedReviewers, reviewerID)
+		pr.Reviewers = append(pr.Reviewers, reviewer)
+		if reviewer.Role != models.RoleShadow {
+			pr.AssignedReviewers = append(pr.AssignedReviewers, reviewer.UserID)
+		}
 	}
 
 	return pr, rows.Err()
@@ -274,38 +564,275 @@ func (r *Repository) UpdatePRStatus(pullRequestID, status string, mergedAt *time
 	return err
 }
 
+// ReplaceReviewer заменяет ревьювера (роль сохраняется) и записывает переназначение в историю
+// вместе со временем замененного назначения
 func (r *Repository) ReplaceReviewer(pullRequestID, oldReviewerID, newReviewerID string) error {
+	return r.InTx(func(tx *Repository) error {
+		_, err := tx.db.Exec(`
+			INSERT INTO pr_reassignments (pull_request_id, old_reviewer_id, new_reviewer_id, old_assigned_at)
+			SELECT pull_request_id, reviewer_id, $3, assigned_at
+			FROM pr_reviewers
+			WHERE pull_request_id = $1 AND reviewer_id = $2
+		`, pullRequestID, oldReviewerID, newReviewerID)
+		if err != nil {
+			return err
+		}
+
+		_, err = tx.db.Exec(`
+			UPDATE pr_reviewers 
+			SET reviewer_id = $1, assigned_at = CURRENT_TIMESTAMP, reminded_at = NULL, verdict = NULL, reviewed_at = NULL, first_reviewed_at = NULL
+			WHERE pull_request_id = $2 AND reviewer_id = $3
+		`, newReviewerID, pullRequestID, oldReviewerID)
+		return err
+	})
+}
+
+func (r *Repository) RemoveReviewer(pullRequestID, reviewerID string) error {
+	_, err := r.db.Exec("DELETE FROM pr_reviewers WHERE pull_request_id = $1 AND reviewer_id = $2",
+		pullRequestID, reviewerID)
+	return err
+}
+
+// priorityOrderSQL порядок приоритетов для сортировки: 0 - hotfix, 3 - low
+const priorityOrderSQL = `CASE pr.priority WHEN 'hotfix' THEN 0 WHEN 'high' THEN 1 WHEN 'normal' THEN 2 ELSE 3 END`
+
+// GetPRsByReviewer возвращает очередь ревьювера с данными его назначения.
+// Сортировка priority: по приоритету (hotfix первыми), затем от старых к новым; age - от старых к новым
+func (r *Repository) GetPRsByReviewer(filter models.ReviewFilter) ([]*models.ReviewItem, error) {
+	w := &whereBuilder{}
+	w.add("prr.reviewer_id = " + w.arg(filter.ReviewerID))
+	if filter.Status != "" {
+		w.add("pr.status = " + w.arg(filter.Status))
+	}
+	if len(filter.Labels) > 0 {
+		labels := w.arg(pq.Array(filter.Labels))
+		w.add(fmt.Sprintf(`pr.pull_request_id IN (
+			SELECT l.pull_request_id FROM pr_labels l
+			WHERE l.label = ANY(%s)
+			GROUP BY l.pull_request_id
+			HAVING COUNT(DISTINCT l.label) = cardinality(%s::text[]))`, labels, labels))
+	}
+
+	orderBy := "pr.created_at, pr.pull_request_id"
+	if filter.Sort == models.ReviewSortPriority {
+		orderBy = priorityOrderSQL + ", " + orderBy
+		if filter.AfterCreatedAt != nil {
+			w.add(fmt.Sprintf("(%s, pr.created_at, pr.pull_request_id) > (%s, %s, %s)", priorityOrderSQL,
+				w.arg(filter.AfterPriority), w.arg(*filter.AfterCreatedAt), w.arg(filter.AfterID)))
+		}
+	} else if filter.AfterCreatedAt != nil {
+		w.add(fmt.Sprintf("(pr.created_at, pr.pull_request_id) > (%s, %s)",
+			w.arg(*filter.AfterCreatedAt), w.arg(filter.AfterID)))
+	}
+
+	limit := ""
+	if filter.Limit > 0 {
+		limit = "LIMIT " + w.arg(filter.Limit)
+	}
+
+	rows, err := r.db.Query(`
+		SELECT pr.pull_request_id, pr.pull_request_name, pr.author_id, pr.status, pr.priority,
+			COALESCE((SELECT array_agg(l.label ORDER BY l.label) FROM pr_labels l
+				WHERE l.pull_request_id = pr.pull_request_id), '{}'),
+			pr.created_at, prr.role, prr.assigned_at, COALESCE(prr.verdict, ''), prr.reviewed_at
+		FROM pull_requests pr
+		INNER JOIN pr_reviewers prr ON pr.pull_request_id = prr.pull_request_id
+		`+w.sql()+`
+		ORDER BY `+orderBy+`
+		`+limit, w.args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var items []*models.ReviewItem
+	for rows.Next() {
+		item := &models.ReviewItem{}
+		var reviewedAt sql.NullTime
+		if err := rows.Scan(&item.PullRequestID, &item.PullRequestName, &item.AuthorID, &item.Status, &item.Priority,
+			pq.Array(&item.Labels), &item.CreatedAt, &item.Role, &item.AssignedAt, &item.Verdict, &reviewedAt); err != nil {
+			return nil, err
+		}
+		if reviewedAt.Valid {
+			item.ReviewedAt = &reviewedAt.Time
+		}
+		items = append(items, item)
+	}
+
+	return items, rows.Err()
+}
+
+// SetReviewVerdict сохраняет вердикт ревьювера; время первого вердикта не перезаписывается
+func (r *Repository) SetReviewVerdict(pullRequestID, reviewerID, verdict string, reviewedAt time.Time) error {
 	_, err := r.db.Exec(`
-		UPDATE pr_reviewers 
-		SET reviewer_id = $1 
-		WHERE pull_request_id = $2 AND reviewer_id = $3
-	`, newReviewerID, pullRequestID, oldReviewerID)
+		UPDATE pr_reviewers
+		SET verdict = $1, reviewed_at = $2, first_reviewed_at = COALESCE(first_reviewed_at, $2)
+		WHERE pull_request_id = $3 AND reviewer_id = $4
+	`, verdict, reviewedAt, pullRequestID, reviewerID)
 	return err
 }
 
-func (r *Repository) GetPRsByReviewer(reviewerID string) ([]*models.PullRequestShort, error) {
+// GetOpenReviewLoad возвращает нагрузку пользователей: суммарную трудоемкость OPEN PR на ревью.
+// Shadow-ревью в нагрузке не учитываются
+func (r *Repository) GetOpenReviewLoad(userIDs []string) (map[string]int, error) {
+	rows, err := r.db.Query(`
+		SELECT prr.reviewer_id, SUM(pr.review_effort)
+		FROM pr_reviewers prr
+		INNER JOIN pull_requests pr ON pr.pull_request_id = prr.pull_request_id
+		WHERE pr.status = 'OPEN' AND prr.role != 'shadow' AND prr.reviewer_id = ANY($1)
+		GROUP BY prr.reviewer_id
+	`, pq.Array(userIDs))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	load := make(map[string]int, len(userIDs))
+	for rows.Next() {
+		var userID string
+		var effort int
+		if err := rows.Scan(&userID, &effort); err != nil {
+			return nil, err
+		}
+		load[userID] = effort
+	}
+	return load, rows.Err()
+}
+
+// GetOpenReviews возвращает OPEN PR, где пользователь назначен ревьювером
+func (r *Repository) GetOpenReviews(reviewerID string) ([]string, error) {
 	rows, err := r.db.Query(`
-		SELECT pr.pull_request_id, pr.pull_request_name, pr.author_id, pr.status
+		SELECT pr.pull_request_id
 		FROM pull_requests pr
 		INNER JOIN pr_reviewers prr ON pr.pull_request_id = prr.pull_request_id
-		WHERE prr.reviewer_id = $1
-		ORDER BY pr.created_at DESC
+		WHERE prr.reviewer_id = $1 AND pr.status = 'OPEN'
+		ORDER BY pr.created_at
 	`, reviewerID)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
 
-	var prs []*models.PullRequestShort
+	var prIDs []string
+	for rows.Next() {
+		var prID string
+		if err := rows.Scan(&prID); err != nil {
+			return nil, err
+		}
+		prIDs = append(prIDs, prID)
+	}
+	return prIDs, rows.Err()
+}
+
+// GetOpenReviewsInTeam возвращает OPEN PR авторов команды teamName, где пользователь назначен ревьювером
+func (r *Repository) GetOpenReviewsInTeam(reviewerID, teamName string) ([]string, error) {
+	rows, err := r.db.Query(`
+		SELECT pr.pull_request_id
+		FROM pull_requests pr
+		INNER JOIN pr_reviewers prr ON pr.pull_request_id = prr.pull_request_id
+		INNER JOIN team_members tm ON tm.user_id = pr.author_id AND tm.team_name = $2
+		WHERE prr.reviewer_id = $1 AND pr.status = 'OPEN'
+		ORDER BY pr.created_at
+	`, reviewerID, teamName)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var prIDs []string
 	for rows.Next() {
-		pr := &models.PullRequestShort{}
-		if err := rows.Scan(&pr.PullRequestID, &pr.PullRequestName, &pr.AuthorID, &pr.Status); err != nil {
+		var prID string
+		if err := rows.Scan(&prID); err != nil {
 			return nil, err
 		}
-		prs = append(prs, pr)
+		prIDs = append(prIDs, prID)
 	}
+	return prIDs, rows.Err()
+}
 
-	return prs, rows.Err()
+// GetReviewersWithOpenReviews возвращает ревьюверов, у которых есть OPEN PR на ревью
+func (r *Repository) GetReviewersWithOpenReviews() ([]string, error) {
+	rows, err := r.db.Query(`
+		SELECT DISTINCT prr.reviewer_id
+		FROM pr_reviewers prr
+		INNER JOIN pull_requests pr ON pr.pull_request_id = prr.pull_request_id
+		WHERE pr.status = 'OPEN'
+		ORDER BY prr.reviewer_id
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var reviewerIDs []string
+	for rows.Next() {
+		var reviewerID string
+		if err := rows.Scan(&reviewerID); err != nil {
+			return nil, err
+		}
+		reviewerIDs = append(reviewerIDs, reviewerID)
+	}
+	return reviewerIDs, rows.Err()
+}
+
+// GetOverdueReviews возвращает назначения на OPEN PR, сделанные раньше before,
+// без вердикта и без отправленного напоминания
+func (r *Repository) GetOverdueReviews(before time.Time) ([]*models.PendingReview, error) {
+	rows, err := r.db.Query(`
+		SELECT prr.reviewer_id, prr.assigned_at,
+			pr.pull_request_id, pr.pull_request_name, pr.author_id, pr.status
+		FROM pr_reviewers prr
+		INNER JOIN pull_requests pr ON pr.pull_request_id = prr.pull_request_id
+		WHERE pr.status = 'OPEN' AND prr.assigned_at < $1 AND prr.reminded_at IS NULL AND prr.reviewed_at IS NULL
+		ORDER BY prr.assigned_at
+	`, before)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var reviews []*models.PendingReview
+	for rows.Next() {
+		review := &models.PendingReview{}
+		if err := rows.Scan(&review.ReviewerID, &review.AssignedAt,
+			&review.PR.PullRequestID, &review.PR.PullRequestName, &review.PR.AuthorID, &review.PR.Status); err != nil {
+			return nil, err
+		}
+		reviews = append(reviews, review)
+	}
+	return reviews, rows.Err()
+}
+
+// MarkReviewReminded отмечает, что напоминание по назначению отправлено
+func (r *Repository) MarkReviewReminded(pullRequestID, reviewerID string) error {
+	_, err := r.db.Exec(`
+		UPDATE pr_reviewers
+		SET reminded_at = CURRENT_TIMESTAMP
+		WHERE pull_request_id = $1 AND reviewer_id = $2
+	`, pullRequestID, reviewerID)
+	return err
+}
+
+// scanPRReviewer читает строку (reviewer_id, role, assigned_at, verdict, reviewed_at)
+func scanPRReviewer(rows *sql.Rows, dest ...interface{}) (models.PRReviewer, error) {
+	var reviewer models.PRReviewer
+	var assignedAt, reviewedAt sql.NullTime
+	dest = append(dest, &reviewer.UserID, &reviewer.Role, &assignedAt, &reviewer.Verdict, &reviewedAt)
+	if err := rows.Scan(dest...); err != nil {
+		return reviewer, err
+	}
+	if assignedAt.Valid {
+		reviewer.AssignedAt = &assignedAt.Time
+	}
+	if reviewedAt.Valid {
+		reviewer.ReviewedAt = &reviewedAt.Time
+	}
+	return reviewer, nil
+}
+
+// escapeLike экранирует спецсимволы шаблона LIKE
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`).Replace(s)
 }
 
 // SelectRandomReviewers выбирает случайных ревьюверов из списка (до 2)
@@ -329,3 +856,36 @@ func SelectRandomReviewers(candidates []*models.User, count int) []*models.User
 
 	return shuffled[:count]
 }
+
+// SelectLeastLoadedReviewers выбирает count наименее загруженных ревьюверов.
+// При равной нагрузке выбор случайный
+func SelectLeastLoadedReviewers(candidates []*models.User, load map[string]int, count int) []*models.User {
+	shuffled := SelectRandomReviewers(candidates, len(candidates))
+	sort.SliceStable(shuffled, func(i, j int) bool {
+		return load[shuffled[i].UserID] < load[shuffled[j].UserID]
+	})
+
+	if count > len(shuffled) {
+		count = len(shuffled)
+	}
+	if count < 0 {
+		count = 0
+	}
+	return shuffled[:count]
+}
+
+// GetTeamChatWebhook возвращает адрес входящего вебхука чата команды; "" - не задан
+func (r *Repository) GetTeamChatWebhook(teamName string) (string, error) {
+	var url string
+	err := r.db.QueryRow(`SELECT chat_webhook_url FROM teams WHERE team_name = $1`, teamName).Scan(&url)
+	if err == sql.ErrNoRows {
+		return "", nil
+	}
+	return url, err
+}
+
+// SetTeamChatWebhook задает адрес входящего вебхука чата команды
+func (r *Repository) SetTeamChatWebhook(teamName, url string) error {
+	_, err := r.db.Exec(`UPDATE teams SET chat_webhook_url = $1 WHERE team_name = $2`, url, teamName)
+	return err
+}
@@ -0,0 +1,262 @@
+package repository
+
+import (
+	"avito/models"
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+// nullTime переводит необязательную границу периода в параметр запроса
+func nullTime(t *time.Time) sql.NullTime {
+	if t == nil {
+		return sql.NullTime{}
+	}
+	return sql.NullTime{Time: *t, Valid: true}
+}
+
+// GetUserAssignmentStats считает назначения и переназначения по пользователям. Назначения, которые
+// потом переназначили на другого, остаются в assigned прежнего ревьювера (по pr_reassignments)
+func (r *Repository) GetUserAssignmentStats(filter models.StatsFilter) ([]models.UserAssignmentStats, error) {
+	stats := []models.UserAssignmentStats{}
+	err := r.EachUserAssignmentStats(filter, func(st models.UserAssignmentStats) error {
+		stats = append(stats, st)
+		return nil
+	})
+	return stats, err
+}
+
+// EachUserAssignmentStats передает fn статистику построчно, не загружая выборку в память
+func (r *Repository) EachUserAssignmentStats(filter models.StatsFilter, fn func(models.UserAssignmentStats) error) error {
+	rows, err := r.db.Query(`
+		WITH assigned AS (
+			SELECT user_id,
+				COUNT(*) AS assigned,
+				COUNT(*) FILTER (WHERE is_open) AS open_reviews
+			FROM (
+				SELECT prr.reviewer_id AS user_id, prr.assigned_at, pr.status = 'OPEN' AS is_open
+				FROM pr_reviewers prr
+				INNER JOIN pull_requests pr ON pr.pull_request_id = prr.pull_request_id
+				UNION ALL
+				SELECT old_reviewer_id, COALESCE(old_assigned_at, reassigned_at), FALSE FROM pr_reassignments
+			) a
+			WHERE ($2::timestamp IS NULL OR a.assigned_at >= $2)
+				AND ($3::timestamp IS NULL OR a.assigned_at < $3)
+			GROUP BY user_id
+		), moved AS (
+			SELECT user_id, SUM(from_count) AS reassigned_from, SUM(to_count) AS reassigned_to
+			FROM (
+				SELECT old_reviewer_id AS user_id, 1 AS from_count, 0 AS to_count, reassigned_at FROM pr_reassignments
+				UNION ALL
+				SELECT new_reviewer_id, 0, 1, reassigned_at FROM pr_reassignments
+			) m
+			WHERE ($2::timestamp IS NULL OR m.reassigned_at >= $2)
+				AND ($3::timestamp IS NULL OR m.reassigned_at < $3)
+			GROUP BY user_id
+		)
+		SELECT u.user_id, u.username,
+			COALESCE(a.assigned, 0), COALESCE(a.open_reviews, 0),
+			COALESCE(m.reassigned_from, 0), COALESCE(m.reassigned_to, 0)
+		FROM users u
+		LEFT JOIN assigned a ON a.user_id = u.user_id
+		LEFT JOIN moved m ON m.user_id = u.user_id
+		WHERE ($1 = '' OR EXISTS (SELECT 1 FROM team_members tm WHERE tm.user_id = u.user_id AND tm.team_name = $1))
+			AND (a.user_id IS NOT NULL OR m.user_id IS NOT NULL)
+		ORDER BY COALESCE(a.assigned, 0) DESC, u.user_id
+	`, filter.TeamName, nullTime(filter.From), nullTime(filter.To))
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var st models.UserAssignmentStats
+		if err := rows.Scan(&st.UserID, &st.Username, &st.Assigned, &st.OpenReviews,
+			&st.ReassignedFrom, &st.ReassignedTo); err != nil {
+			return err
+		}
+		if err := fn(st); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
+
+// GetPRAssignmentStats считает ревьюверов и переназначения по PR, созданным в периоде
+func (r *Repository) GetPRAssignmentStats(filter models.StatsFilter) ([]models.PRAssignmentStats, error) {
+	stats := []models.PRAssignmentStats{}
+	err := r.EachPRAssignmentStats(filter, func(st models.PRAssignmentStats) error {
+		stats = append(stats, st)
+		return nil
+	})
+	return stats, err
+}
+
+// EachPRAssignmentStats построчный вариант GetPRAssignmentStats
+func (r *Repository) EachPRAssignmentStats(filter models.StatsFilter, fn func(models.PRAssignmentStats) error) error {
+	rows, err := r.db.Query(`
+		SELECT pr.pull_request_id, pr.author_id, pr.status,
+			(SELECT COUNT(*) FROM pr_reviewers prr WHERE prr.pull_request_id = pr.pull_request_id),
+			(SELECT COUNT(*) FROM pr_reassignments ra WHERE ra.pull_request_id = pr.pull_request_id)
+		FROM pull_requests pr
+		WHERE ($1 = '' OR EXISTS (SELECT 1 FROM team_members tm WHERE tm.user_id = pr.author_id AND tm.team_name = $1))
+			AND ($2::timestamp IS NULL OR pr.created_at >= $2)
+			AND ($3::timestamp IS NULL OR pr.created_at < $3)
+		ORDER BY pr.created_at DESC, pr.pull_request_id
+	`, filter.TeamName, nullTime(filter.From), nullTime(filter.To))
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var st models.PRAssignmentStats
+		if err := rows.Scan(&st.PullRequestID, &st.AuthorID, &st.Status, &st.Reviewers, &st.Reassignments); err != nil {
+			return err
+		}
+		if err := fn(st); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
+
+// GetTeamPRStats считает PR, открытые и смерженные авторами команд в периоде
+func (r *Repository) GetTeamPRStats(filter models.StatsFilter) ([]models.TeamPRStats, error) {
+	stats := []models.TeamPRStats{}
+	err := r.EachTeamPRStats(filter, func(st models.TeamPRStats) error {
+		stats = append(stats, st)
+		return nil
+	})
+	return stats, err
+}
+
+// EachTeamPRStats построчный вариант GetTeamPRStats
+func (r *Repository) EachTeamPRStats(filter models.StatsFilter, fn func(models.TeamPRStats) error) error {
+	rows, err := r.db.Query(`
+		SELECT t.team_name,
+			COUNT(DISTINCT pr.pull_request_id) FILTER (
+				WHERE ($2::timestamp IS NULL OR pr.created_at >= $2) AND ($3::timestamp IS NULL OR pr.created_at < $3)),
+			COUNT(DISTINCT pr.pull_request_id) FILTER (
+				WHERE pr.merged_at IS NOT NULL
+					AND ($2::timestamp IS NULL OR pr.merged_at >= $2) AND ($3::timestamp IS NULL OR pr.merged_at < $3)),
+			COUNT(DISTINCT ra.id) FILTER (
+				WHERE ($2::timestamp IS NULL OR ra.reassigned_at >= $2) AND ($3::timestamp IS NULL OR ra.reassigned_at < $3))
+		FROM teams t
+		LEFT JOIN team_members tm ON tm.team_name = t.team_name
+		LEFT JOIN pull_requests pr ON pr.author_id = tm.user_id
+		LEFT JOIN pr_reassignments ra ON ra.pull_request_id = pr.pull_request_id
+		WHERE ($1 = '' OR t.team_name = $1)
+		GROUP BY t.team_name
+		ORDER BY t.team_name
+	`, filter.TeamName, nullTime(filter.From), nullTime(filter.To))
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var st models.TeamPRStats
+		if err := rows.Scan(&st.TeamName, &st.PRsOpened, &st.PRsMerged, &st.Reassignments); err != nil {
+			return err
+		}
+		if err := fn(st); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
+
+// percentilesSQL перцентили p50/p90 длительности expr в часах
+const percentilesSQL = `COUNT(*),
+	percentile_cont(0.5) WITHIN GROUP (ORDER BY EXTRACT(EPOCH FROM %[1]s)) / 3600,
+	percentile_cont(0.9) WITHIN GROUP (ORDER BY EXTRACT(EPOCH FROM %[1]s)) / 3600`
+
+// GetTimeToMerge считает перцентили времени от создания до merge по командам или авторам
+// для PR, смерженных в периоде
+func (r *Repository) GetTimeToMerge(filter models.StatsFilter, groupByTeam bool) ([]models.DurationStats, error) {
+	key := "pr.author_id"
+	join := ""
+	if groupByTeam {
+		key = "tm.team_name"
+		join = "INNER JOIN team_members tm ON tm.user_id = pr.author_id"
+	}
+
+	rows, err := r.db.Query(`
+		SELECT `+key+`, `+fmt.Sprintf(percentilesSQL, "(pr.merged_at - pr.created_at)")+`
+		FROM pull_requests pr
+		`+join+`
+		WHERE pr.merged_at IS NOT NULL
+			AND ($1 = '' OR EXISTS (SELECT 1 FROM team_members f WHERE f.user_id = pr.author_id AND f.team_name = $1))
+			AND ($2::timestamp IS NULL OR pr.merged_at >= $2)
+			AND ($3::timestamp IS NULL OR pr.merged_at < $3)
+		GROUP BY `+key+`
+		ORDER BY `+key, filter.TeamName, nullTime(filter.From), nullTime(filter.To))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	return scanDurationStats(rows)
+}
+
+// GetTimeToFirstReview считает перцентили времени от назначения до первого вердикта по ревьюверам
+// для первых вердиктов, вынесенных в периоде
+func (r *Repository) GetTimeToFirstReview(filter models.StatsFilter) ([]models.DurationStats, error) {
+	rows, err := r.db.Query(`
+		SELECT prr.reviewer_id, `+fmt.Sprintf(percentilesSQL, "(prr.first_reviewed_at - prr.assigned_at)")+`
+		FROM pr_reviewers prr
+		WHERE prr.first_reviewed_at IS NOT NULL
+			AND ($1 = '' OR EXISTS (SELECT 1 FROM team_members f WHERE f.user_id = prr.reviewer_id AND f.team_name = $1))
+			AND ($2::timestamp IS NULL OR prr.first_reviewed_at >= $2)
+			AND ($3::timestamp IS NULL OR prr.first_reviewed_at < $3)
+		GROUP BY prr.reviewer_id
+		ORDER BY prr.reviewer_id
+	`, filter.TeamName, nullTime(filter.From), nullTime(filter.To))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	return scanDurationStats(rows)
+}
+
+// GetTimeToMergeSeries считает перцентили времени до merge по интервалам bucket (day, week, month)
+func (r *Repository) GetTimeToMergeSeries(filter models.StatsFilter, bucket string) ([]models.DurationBucket, error) {
+	rows, err := r.db.Query(`
+		SELECT date_trunc($4, pr.merged_at) AS bucket, `+fmt.Sprintf(percentilesSQL, "(pr.merged_at - pr.created_at)")+`
+		FROM pull_requests pr
+		WHERE pr.merged_at IS NOT NULL
+			AND ($1 = '' OR EXISTS (SELECT 1 FROM team_members f WHERE f.user_id = pr.author_id AND f.team_name = $1))
+			AND ($2::timestamp IS NULL OR pr.merged_at >= $2)
+			AND ($3::timestamp IS NULL OR pr.merged_at < $3)
+		GROUP BY bucket
+		ORDER BY bucket
+	`, filter.TeamName, nullTime(filter.From), nullTime(filter.To), bucket)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	series := []models.DurationBucket{}
+	for rows.Next() {
+		var b models.DurationBucket
+		if err := rows.Scan(&b.BucketStart, &b.Count, &b.P50Hours, &b.P90Hours); err != nil {
+			return nil, err
+		}
+		series = append(series, b)
+	}
+	return series, rows.Err()
+}
+
+func scanDurationStats(rows *sql.Rows) ([]models.DurationStats, error) {
+	stats := []models.DurationStats{}
+	for rows.Next() {
+		var st models.DurationStats
+		if err := rows.Scan(&st.Key, &st.Count, &st.P50Hours, &st.P90Hours); err != nil {
+			return nil, err
+		}
+		stats = append(stats, st)
+	}
+	return stats, rows.Err()
+}
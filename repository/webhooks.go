@@ -0,0 +1,206 @@
+package repository
+
+import (
+	"avito/models"
+	"database/sql"
+	"encoding/json"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// CreateWebhook создает подписку
+func (r *Repository) CreateWebhook(webhook *models.Webhook) error {
+	return r.db.QueryRow(`
+		INSERT INTO webhooks (url, secret, events)
+		VALUES ($1, $2, $3)
+		RETURNING id, created_at
+	`, webhook.URL, webhook.Secret, pq.Array(webhook.Events)).Scan(&webhook.ID, &webhook.CreatedAt)
+}
+
+// ListWebhooks возвращает все подписки
+func (r *Repository) ListWebhooks() ([]models.Webhook, error) {
+	rows, err := r.db.Query(`SELECT id, url, secret, events, created_at FROM webhooks ORDER BY id`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	webhooks := []models.Webhook{}
+	for rows.Next() {
+		var wh models.Webhook
+		if err := rows.Scan(&wh.ID, &wh.URL, &wh.Secret, pq.Array(&wh.Events), &wh.CreatedAt); err != nil {
+			return nil, err
+		}
+		webhooks = append(webhooks, wh)
+	}
+	return webhooks, rows.Err()
+}
+
+// DeleteWebhook удаляет подписку вместе с журналом ее доставок; false - подписки нет
+func (r *Repository) DeleteWebhook(id int64) (bool, error) {
+	res, err := r.db.Exec(`DELETE FROM webhooks WHERE id = $1`, id)
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	return n > 0, err
+}
+
+// AddWebhookDeliveries ставит событие в очередь доставки всем подпискам, чей фильтр его пропускает:
+// пустой список событий, точный тип или шаблон "<префикс>.*"
+func (r *Repository) AddWebhookDeliveries(ev *models.OutboxEvent) error {
+	body, err := json.Marshal(ev)
+	if err != nil {
+		return err
+	}
+
+	_, err = r.db.Exec(`
+		INSERT INTO webhook_deliveries (webhook_id, event_id, event_type, payload)
+		SELECT id, $1, $2, $3::jsonb
+		FROM webhooks
+		WHERE cardinality(events) = 0
+			OR $2 = ANY(events)
+			OR split_part($2, '.', 1) || '.*' = ANY(events)
+	`, ev.ID, ev.Type, string(body))
+	return err
+}
+
+const webhookDeliveryColumnsSQL = `d.id, d.webhook_id, d.event_id, d.event_type, d.payload, d.status, d.attempts,
+	d.last_status_code, d.last_error, d.next_attempt_at, d.created_at, d.delivered_at`
+
+func scanWebhookDelivery(row interface{ Scan(...interface{}) error }, d *models.WebhookDelivery, extra ...interface{}) error {
+	var payload []byte
+	var statusCode sql.NullInt64
+	var lastError sql.NullString
+	var nextAttemptAt time.Time
+	var deliveredAt sql.NullTime
+	dest := append([]interface{}{&d.ID, &d.WebhookID, &d.EventID, &d.EventType, &payload, &d.Status, &d.Attempts,
+		&statusCode, &lastError, &nextAttemptAt, &d.CreatedAt, &deliveredAt}, extra...)
+	if err := row.Scan(dest...); err != nil {
+		return err
+	}
+
+	d.Payload = json.RawMessage(payload)
+	if statusCode.Valid {
+		code := int(statusCode.Int64)
+		d.LastStatusCode = &code
+	}
+	d.LastError = lastError.String
+	if d.Status == models.DeliveryPending {
+		d.NextAttemptAt = &nextAttemptAt
+	}
+	if deliveredAt.Valid {
+		d.DeliveredAt = &deliveredAt.Time
+	}
+	return nil
+}
+
+// ListWebhookDeliveries возвращает журнал доставок от новых к старым с keyset-пагинацией по id
+func (r *Repository) ListWebhookDeliveries(filter models.WebhookDeliveryFilter) ([]*models.WebhookDelivery, error) {
+	w := &whereBuilder{}
+	if filter.WebhookID != 0 {
+		w.add("d.webhook_id = " + w.arg(filter.WebhookID))
+	}
+	if filter.Status != "" {
+		w.add("d.status = " + w.arg(filter.Status))
+	}
+	if filter.BeforeID != 0 {
+		w.add("d.id < " + w.arg(filter.BeforeID))
+	}
+
+	limit := w.arg(filter.Limit)
+	rows, err := r.db.Query(`
+		SELECT `+webhookDeliveryColumnsSQL+`
+		FROM webhook_deliveries d
+		`+w.sql()+`
+		ORDER BY d.id DESC
+		LIMIT `+limit, w.args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	deliveries := []*models.WebhookDelivery{}
+	for rows.Next() {
+		d := &models.WebhookDelivery{}
+		if err := scanWebhookDelivery(rows, d); err != nil {
+			return nil, err
+		}
+		deliveries = append(deliveries, d)
+	}
+	return deliveries, rows.Err()
+}
+
+// RequeueWebhookDelivery создает новую доставку того же события тому же подписчику
+func (r *Repository) RequeueWebhookDelivery(id int64) (*models.WebhookDelivery, error) {
+	d := &models.WebhookDelivery{}
+	err := scanWebhookDelivery(r.db.QueryRow(`
+		WITH d AS (
+			INSERT INTO webhook_deliveries (webhook_id, event_id, event_type, payload)
+			SELECT webhook_id, event_id, event_type, payload FROM webhook_deliveries WHERE id = $1
+			RETURNING *
+		)
+		SELECT `+webhookDeliveryColumnsSQL+` FROM d
+	`, id), d)
+	if err != nil {
+		return nil, err
+	}
+	return d, nil
+}
+
+// ClaimWebhookDeliveries захватывает ожидающие доставки, для которых наступило время попытки,
+// и возвращает их вместе с подписками: следующая попытка переносится на leaseUntil, и до этого
+// доставки не выдаются другим экземплярам. Строки, которые захватывает другой экземпляр, пропускаются
+func (r *Repository) ClaimWebhookDeliveries(now, leaseUntil time.Time, limit int) ([]*models.WebhookDelivery, []*models.Webhook, error) {
+	rows, err := r.db.Query(`
+		WITH claimed AS (
+			SELECT id FROM webhook_deliveries
+			WHERE status = 'pending' AND next_attempt_at <= $1
+			ORDER BY id
+			LIMIT $3
+			FOR UPDATE SKIP LOCKED
+		), d AS (
+			UPDATE webhook_deliveries SET next_attempt_at = $2
+			WHERE id IN (SELECT id FROM claimed)
+			RETURNING *
+		)
+		SELECT `+webhookDeliveryColumnsSQL+`, w.url, w.secret
+		FROM d
+		JOIN webhooks w ON w.id = d.webhook_id
+		ORDER BY d.id
+	`, now, leaseUntil, limit)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer rows.Close()
+
+	var deliveries []*models.WebhookDelivery
+	var webhooks []*models.Webhook
+	for rows.Next() {
+		d := &models.WebhookDelivery{}
+		wh := &models.Webhook{}
+		if err := scanWebhookDelivery(rows, d, &wh.URL, &wh.Secret); err != nil {
+			return nil, nil, err
+		}
+		wh.ID = d.WebhookID
+		deliveries = append(deliveries, d)
+		webhooks = append(webhooks, wh)
+	}
+	return deliveries, webhooks, rows.Err()
+}
+
+// UpdateWebhookDelivery сохраняет результат попытки доставки
+func (r *Repository) UpdateWebhookDelivery(d *models.WebhookDelivery) error {
+	var nextAttemptAt interface{} = time.Now()
+	if d.NextAttemptAt != nil {
+		nextAttemptAt = *d.NextAttemptAt
+	}
+	_, err := r.db.Exec(`
+		UPDATE webhook_deliveries
+		SET status = $2, attempts = $3, last_status_code = $4, last_error = NULLIF($5, ''),
+			next_attempt_at = $6, delivered_at = $7
+		WHERE id = $1
+	`, d.ID, d.Status, d.Attempts, d.LastStatusCode, d.LastError, nextAttemptAt, d.DeliveredAt)
+	return err
+}
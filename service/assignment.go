@@ -0,0 +1,188 @@
+package service
+
+import (
+	"avito/models"
+	"avito/repository"
+	"fmt"
+)
+
+// selectReviewers подбирает ревьюверов PR из кандидатов команды.
+// Первые два ревьювера обязательные, остальные дополнительные. Если в команде есть
+// обучающиеся ревьюверы, один из них добавляется как shadow, но только в паре с senior
+func (s *Service) selectReviewers(candidates []*models.User, count int, priority string) ([]models.PRReviewer, error) {
+	regular, learners := splitLearners(candidates)
+
+	// Для hotfix выбираем наименее загруженных ревьюверов, иначе случайных
+	var chosen []*models.User
+	if priority == models.PriorityHotfix {
+		load, err := s.repo.GetOpenReviewLoad(userIDs(regular))
+		if err != nil {
+			return nil, fmt.Errorf("failed to get reviewer load: %w", err)
+		}
+		chosen = repository.SelectLeastLoadedReviewers(regular, load, count)
+	} else {
+		chosen = repository.SelectRandomReviewers(regular, count)
+	}
+
+	var shadow *models.User
+	if len(learners) > 0 {
+		var paired bool
+		chosen, paired = ensureSenior(chosen, regular, count)
+		if paired {
+			shadow = repository.SelectRandomReviewers(learners, 1)[0]
+		}
+	}
+
+	reviewers := make([]models.PRReviewer, 0, len(chosen)+1)
+	for i, u := range chosen {
+		role := models.RoleRequired
+		if i >= 2 {
+			role = models.RoleOptional
+		}
+		reviewers = append(reviewers, models.PRReviewer{UserID: u.UserID, Role: role})
+	}
+	if shadow != nil {
+		reviewers = append(reviewers, models.PRReviewer{UserID: shadow.UserID, Role: models.RoleShadow})
+	}
+
+	return reviewers, nil
+}
+
+// splitLearners разделяет кандидатов на обычных и обучающихся ревьюверов
+func splitLearners(candidates []*models.User) (regular, learners []*models.User) {
+	for _, c := range candidates {
+		if c.IsLearningReviewer {
+			learners = append(learners, c)
+		} else {
+			regular = append(regular, c)
+		}
+	}
+	return regular, learners
+}
+
+// ensureSenior гарантирует, что среди выбранных есть senior: при необходимости
+// добавляет его на свободное место или заменяет последнего выбранного.
+// Возвращает false, если подходящего senior нет
+func ensureSenior(chosen, pool []*models.User, count int) ([]*models.User, bool) {
+	for _, u := range chosen {
+		if u.IsSenior {
+			return chosen, true
+		}
+	}
+	if count <= 0 {
+		return chosen, false
+	}
+
+	seniors := []*models.User{}
+	for _, u := range pool {
+		if u.IsSenior && !containsUser(chosen, u.UserID) {
+			seniors = append(seniors, u)
+		}
+	}
+	if len(seniors) == 0 {
+		return chosen, false
+	}
+
+	senior := repository.SelectRandomReviewers(seniors, 1)[0]
+	if len(chosen) < count {
+		return append(chosen, senior), true
+	}
+
+	result := make([]*models.User, len(chosen))
+	copy(result, chosen)
+	result[len(result)-1] = senior
+	return result, true
+}
+
+// replacementCandidates отбирает кандидатов на замену ревьювера с ролью role:
+// shadow меняется только на обучающегося, остальные роли - на обычного ревьювера.
+// Если заменяется единственный senior в PR с shadow, предпочтение отдается senior
+func (s *Service) replacementCandidates(pr *models.PullRequest, oldUserID, role string, candidates []*models.User) ([]*models.User, error) {
+	available := []*models.User{}
+	for _, c := range candidates {
+		if c.UserID == pr.AuthorID || c.UserID == oldUserID || hasReviewer(pr, c.UserID) {
+			continue
+		}
+		if c.IsLearningReviewer != (role == models.RoleShadow) {
+			continue
+		}
+		available = append(available, c)
+	}
+
+	if role == models.RoleShadow {
+		return available, nil
+	}
+	needSenior, err := s.needsSeniorReplacement(pr, oldUserID)
+	if err != nil {
+		return nil, err
+	}
+	if !needSenior {
+		return available, nil
+	}
+
+	seniors := []*models.User{}
+	for _, c := range available {
+		if c.IsSenior {
+			seniors = append(seniors, c)
+		}
+	}
+	if len(seniors) == 0 {
+		return available, nil
+	}
+	return seniors, nil
+}
+
+// needsSeniorReplacement проверяет, что в PR есть shadow, а oldUserID - единственный senior среди остальных ревьюверов
+func (s *Service) needsSeniorReplacement(pr *models.PullRequest, oldUserID string) (bool, error) {
+	hasShadow := false
+	for _, r := range pr.Reviewers {
+		if r.Role == models.RoleShadow {
+			hasShadow = true
+		}
+	}
+	if !hasShadow {
+		return false, nil
+	}
+
+	oldIsSenior := false
+	for _, r := range pr.Reviewers {
+		if r.Role == models.RoleShadow {
+			continue
+		}
+		user, err := s.repo.GetUser(r.UserID)
+		if err != nil {
+			return false, fmt.Errorf("failed to get reviewer %s: %w", r.UserID, err)
+		}
+		if !user.IsSenior {
+			continue
+		}
+		if r.UserID != oldUserID {
+			return false, nil
+		}
+		oldIsSenior = true
+	}
+	return oldIsSenior, nil
+}
+
+// reviewerRole возвращает роль ревьювера в PR или пустую строку, если он не назначен
+func reviewerRole(pr *models.PullRequest, userID string) string {
+	for _, r := range pr.Reviewers {
+		if r.UserID == userID {
+			return r.Role
+		}
+	}
+	return ""
+}
+
+func hasReviewer(pr *models.PullRequest, userID string) bool {
+	return reviewerRole(pr, userID) != ""
+}
+
+func containsUser(users []*models.User, userID string) bool {
+	for _, u := range users {
+		if u.UserID == userID {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,379 @@
+package service
+
+import (
+	"avito/models"
+	"context"
+	"fmt"
+	"log"
+	"strconv"
+	"time"
+)
+
+// Мутирующие операции сервиса записываются в журнал аудита: состояние сущности до и после
+// снимается в той же транзакции, что и само изменение; там же по нему публикуются события
+// команд и пользователей в outbox. Операции над пользователем публикуют и team.changed его команд.
+// Реализация операций - в одноименных неэкспортируемых методах
+
+// CreateTeam создает команду с участниками (создает/обновляет пользователей)
+func (s *Service) CreateTeam(req *models.CreateTeamRequest) (*models.Team, error) {
+	if req == nil {
+		return nil, fmt.Errorf("request cannot be nil")
+	}
+	var team *models.Team
+	err := s.withAudit(models.AuditEntityTeam, req.TeamName, func(tx *Service) (err error) {
+		team, err = tx.createTeam(req)
+		return err
+	})
+	return team, err
+}
+
+// SetTeamPolicy изменяет политику назначения ревьюверов в команде
+func (s *Service) SetTeamPolicy(req *models.SetTeamPolicyRequest) (*models.Team, error) {
+	if req == nil {
+		return nil, fmt.Errorf("request cannot be nil")
+	}
+	var team *models.Team
+	err := s.withAudit(models.AuditEntityTeam, req.TeamName, func(tx *Service) (err error) {
+		team, err = tx.setTeamPolicy(req)
+		return err
+	})
+	return team, err
+}
+
+// SetTeamChatWebhook задает входящий вебхук чата команды
+func (s *Service) SetTeamChatWebhook(req *models.SetTeamChatWebhookRequest) (*models.Team, error) {
+	if req == nil {
+		return nil, fmt.Errorf("request cannot be nil")
+	}
+	var team *models.Team
+	err := s.withAudit(models.AuditEntityTeam, req.TeamName, func(tx *Service) (err error) {
+		team, err = tx.setTeamChatWebhook(req)
+		return err
+	})
+	return team, err
+}
+
+// SetTeamParent переносит команду под другую родительскую команду
+func (s *Service) SetTeamParent(req *models.SetTeamParentRequest) (*models.Team, error) {
+	if req == nil {
+		return nil, fmt.Errorf("request cannot be nil")
+	}
+	var team *models.Team
+	err := s.withAudit(models.AuditEntityTeam, req.TeamName, func(tx *Service) (err error) {
+		team, err = tx.setTeamParent(req)
+		return err
+	})
+	return team, err
+}
+
+// RenameTeam переименовывает команду; в журнале состояние "после" снимается под новым именем
+func (s *Service) RenameTeam(req *models.RenameTeamRequest) (*models.Team, error) {
+	if req == nil {
+		return nil, fmt.Errorf("request cannot be nil")
+	}
+	var team *models.Team
+	err := s.auditChange(models.AuditEntityTeam, req.TeamName, req.NewTeamName, func(tx *Service) (err error) {
+		team, err = tx.renameTeam(req)
+		return err
+	})
+	return team, err
+}
+
+// DeleteTeam удаляет команду, переназначая открытые ревью ее участников на reassign_to
+func (s *Service) DeleteTeam(req *models.DeleteTeamRequest) (*models.DeleteTeamResponse, error) {
+	if req == nil {
+		return nil, fmt.Errorf("request cannot be nil")
+	}
+	var resp *models.DeleteTeamResponse
+	err := s.withAudit(models.AuditEntityTeam, req.TeamName, func(tx *Service) (err error) {
+		resp, err = tx.deleteTeam(req)
+		return err
+	})
+	return resp, err
+}
+
+// UpsertTeam приводит команду к переданному описанию
+func (s *Service) UpsertTeam(req *models.UpsertTeamRequest) (*models.UpsertTeamResponse, error) {
+	if req == nil {
+		return nil, fmt.Errorf("request cannot be nil")
+	}
+	var resp *models.UpsertTeamResponse
+	err := s.withAudit(models.AuditEntityTeam, req.TeamName, func(tx *Service) (err error) {
+		resp, err = tx.upsertTeam(req)
+		return err
+	})
+	return resp, err
+}
+
+// AddTeamMember добавляет пользователя в существующую команду, при необходимости создавая его
+func (s *Service) AddTeamMember(req *models.AddTeamMemberRequest) (*models.Team, error) {
+	if req == nil {
+		return nil, fmt.Errorf("request cannot be nil")
+	}
+	var team *models.Team
+	err := s.withAudit(models.AuditEntityTeam, req.TeamName, func(tx *Service) (err error) {
+		team, err = tx.addTeamMember(req)
+		return err
+	})
+	return team, err
+}
+
+// RemoveTeamMember удаляет пользователя из команды, при необходимости переназначая его открытые ревью
+func (s *Service) RemoveTeamMember(req *models.RemoveTeamMemberRequest) (*models.RemoveTeamMemberResponse, error) {
+	if req == nil {
+		return nil, fmt.Errorf("request cannot be nil")
+	}
+	var resp *models.RemoveTeamMemberResponse
+	err := s.withAudit(models.AuditEntityTeam, req.TeamName, func(tx *Service) (err error) {
+		resp, err = tx.removeTeamMember(req)
+		return err
+	})
+	return resp, err
+}
+
+// MoveTeamMember переводит пользователя из одной команды в другую (в журнале - как изменение пользователя,
+// в outbox - и team.changed обеих команд)
+func (s *Service) MoveTeamMember(req *models.MoveTeamMemberRequest) (*models.MoveTeamMemberResponse, error) {
+	if req == nil {
+		return nil, fmt.Errorf("request cannot be nil")
+	}
+	var resp *models.MoveTeamMemberResponse
+	err := s.withAudit(models.AuditEntityUser, req.UserID, func(tx *Service) error {
+		return tx.withTeamEvents(req.UserID, []string{req.ToTeam}, func() (err error) {
+			resp, err = tx.moveTeamMember(req)
+			return err
+		})
+	})
+	return resp, err
+}
+
+// SetReviewerProfile изменяет флаги наставничества пользователя
+func (s *Service) SetReviewerProfile(req *models.SetReviewerProfileRequest) (*models.User, error) {
+	if req == nil {
+		return nil, fmt.Errorf("request cannot be nil")
+	}
+	var user *models.User
+	err := s.withAudit(models.AuditEntityUser, req.UserID, func(tx *Service) (err error) {
+		user, err = tx.setReviewerProfile(req)
+		return err
+	})
+	return user, err
+}
+
+// SetUserActive устанавливает флаг активности пользователя
+func (s *Service) SetUserActive(userID string, isActive bool) (*models.User, error) {
+	var user *models.User
+	err := s.withAudit(models.AuditEntityUser, userID, func(tx *Service) error {
+		return tx.withTeamEvents(userID, nil, func() (err error) {
+			user, err = tx.setUserActive(userID, isActive)
+			return err
+		})
+	})
+	return user, err
+}
+
+// SetUserSkills заменяет список навыков пользователя
+func (s *Service) SetUserSkills(req *models.SetUserSkillsRequest) (*models.User, error) {
+	if req == nil {
+		return nil, fmt.Errorf("request cannot be nil")
+	}
+	var user *models.User
+	err := s.withAudit(models.AuditEntityUser, req.UserID, func(tx *Service) (err error) {
+		user, err = tx.setUserSkills(req)
+		return err
+	})
+	return user, err
+}
+
+// OffboardUser увольняет пользователя, переназначая его открытые ревью
+func (s *Service) OffboardUser(req *models.OffboardUserRequest) (*models.OffboardUserResponse, error) {
+	if req == nil {
+		return nil, fmt.Errorf("request cannot be nil")
+	}
+	var resp *models.OffboardUserResponse
+	err := s.withAudit(models.AuditEntityUser, req.UserID, func(tx *Service) error {
+		return tx.withTeamEvents(req.UserID, nil, func() (err error) {
+			resp, err = tx.offboardUser(req)
+			return err
+		})
+	})
+	return resp, err
+}
+
+// CreatePR создает новый PR и автоматически назначает ревьюверов
+func (s *Service) CreatePR(req *models.CreatePRRequest) (*models.PullRequest, error) {
+	if req == nil {
+		return nil, fmt.Errorf("request cannot be nil")
+	}
+	var pr *models.PullRequest
+	err := s.withAudit(models.AuditEntityPR, req.PullRequestID, func(tx *Service) (err error) {
+		pr, err = tx.createPR(req)
+		return err
+	})
+	return pr, err
+}
+
+// ReassignReviewer переназначает ревьювера
+func (s *Service) ReassignReviewer(pullRequestID, oldUserID string) (*models.PullRequest, string, error) {
+	var pr *models.PullRequest
+	var newReviewerID string
+	err := s.withAudit(models.AuditEntityPR, pullRequestID, func(tx *Service) (err error) {
+		pr, newReviewerID, err = tx.reassignReviewer(pullRequestID, oldUserID)
+		return err
+	})
+	return pr, newReviewerID, err
+}
+
+// MergePR выполняет merge PR (идемпотентная операция)
+func (s *Service) MergePR(pullRequestID string) (*models.PullRequest, error) {
+	var pr *models.PullRequest
+	err := s.withAudit(models.AuditEntityPR, pullRequestID, func(tx *Service) (err error) {
+		pr, err = tx.mergePR(pullRequestID)
+		return err
+	})
+	return pr, err
+}
+
+// ClosePR закрывает PR без merge (идемпотентная операция)
+func (s *Service) ClosePR(pullRequestID, reason string) (*models.PullRequest, error) {
+	var pr *models.PullRequest
+	err := s.withAudit(models.AuditEntityPR, pullRequestID, func(tx *Service) (err error) {
+		pr, err = tx.closePR(pullRequestID, reason)
+		return err
+	})
+	return pr, err
+}
+
+// ReopenPR возвращает закрытый PR в работу (идемпотентная операция)
+func (s *Service) ReopenPR(pullRequestID string) (*models.PullRequest, error) {
+	var pr *models.PullRequest
+	err := s.withAudit(models.AuditEntityPR, pullRequestID, func(tx *Service) (err error) {
+		pr, err = tx.reopenPR(pullRequestID)
+		return err
+	})
+	return pr, err
+}
+
+// SubmitReview сохраняет вердикт ревьювера по PR
+func (s *Service) SubmitReview(req *models.SubmitReviewRequest) (*models.PullRequest, error) {
+	if req == nil {
+		return nil, fmt.Errorf("request cannot be nil")
+	}
+	var pr *models.PullRequest
+	err := s.withAudit(models.AuditEntityPR, req.PullRequestID, func(tx *Service) (err error) {
+		pr, err = tx.submitReview(req)
+		return err
+	})
+	return pr, err
+}
+
+// withAudit выполняет fn в транзакции и записывает в журнал состояние сущности до и после
+func (s *Service) withAudit(entityType, entityID string, fn func(tx *Service) error) error {
+	return s.auditChange(entityType, entityID, entityID, fn)
+}
+
+// auditChange как withAudit, но состояние после изменения снимается по afterID (для переименований)
+func (s *Service) auditChange(entityType, beforeID, afterID string, fn func(tx *Service) error) error {
+	return s.inTx(func(tx *Service) error {
+		before, err := tx.snapshot(entityType, beforeID)
+		if err != nil {
+			return fmt.Errorf("failed to capture %s state: %w", entityType, err)
+		}
+		if err := fn(tx); err != nil {
+			return err
+		}
+		after, err := tx.snapshot(entityType, afterID)
+		if err != nil {
+			return fmt.Errorf("failed to capture %s state: %w", entityType, err)
+		}
+
+		entry := &models.AuditEntry{
+			Actor:      tx.caller.Actor,
+			Endpoint:   tx.caller.Endpoint,
+			RequestID:  tx.caller.RequestID,
+			EntityType: entityType,
+			EntityID:   beforeID,
+		}
+		if err := tx.repo.AddAuditEntry(entry, before, after); err != nil {
+			return fmt.Errorf("failed to write audit log: %w", err)
+		}
+		return tx.publishStateChange(entityType, beforeID, afterID, before, after)
+	})
+}
+
+// snapshot возвращает текущее состояние сущности или nil, если ее нет
+func (s *Service) snapshot(entityType, entityID string) (interface{}, error) {
+	switch entityType {
+	case models.AuditEntityTeam:
+		exists, err := s.repo.TeamExists(entityID)
+		if err != nil || !exists {
+			return nil, err
+		}
+		return s.repo.GetTeam(entityID)
+	case models.AuditEntityUser:
+		users, err := s.repo.ListUsers(models.UserFilter{UserID: entityID, Limit: 1})
+		if err != nil || len(users) == 0 {
+			return nil, err
+		}
+		return users[0], nil
+	case models.AuditEntityPR:
+		exists, err := s.repo.PRExists(entityID)
+		if err != nil || !exists {
+			return nil, err
+		}
+		return s.repo.GetPR(entityID)
+	default:
+		return nil, fmt.Errorf("unknown entity type: %s", entityType)
+	}
+}
+
+// ListAuditLog возвращает страницу журнала аудита от новых записей к старым
+func (s *Service) ListAuditLog(filter models.AuditFilter, cursor string) (*models.AuditListResponse, error) {
+	if filter.From != nil && filter.To != nil && !filter.From.Before(*filter.To) {
+		return nil, fmt.Errorf("from must be before to")
+	}
+	if cursor != "" {
+		keys, err := decodeCursor(cursor, 1)
+		if err != nil {
+			return nil, err
+		}
+		beforeID, err := strconv.ParseInt(keys[0], 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid cursor")
+		}
+		filter.BeforeID = beforeID
+	}
+
+	limit := pageLimit(filter.Limit)
+	filter.Limit = limit + 1
+	entries, err := s.repo.ListAuditEntries(filter)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list audit log: %w", err)
+	}
+
+	resp := &models.AuditListResponse{Entries: []models.AuditEntry{}}
+	for i, entry := range entries {
+		if i == limit {
+			resp.NextCursor = encodeCursor(strconv.FormatInt(entries[i-1].ID, 10))
+			break
+		}
+		resp.Entries = append(resp.Entries, *entry)
+	}
+	return resp, nil
+}
+
+// PurgeAuditLog удаляет записи журнала старше retention
+func (s *Service) PurgeAuditLog(ctx context.Context, retention time.Duration) error {
+	if retention <= 0 {
+		return fmt.Errorf("audit retention must be positive")
+	}
+
+	deleted, err := s.repo.DeleteAuditEntriesBefore(time.Now().Add(-retention))
+	if err != nil {
+		return fmt.Errorf("failed to purge audit log: %w", err)
+	}
+	if deleted > 0 {
+		log.Printf("Purged %d audit log entries older than %s", deleted, retention)
+	}
+	return nil
+}
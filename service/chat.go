@@ -0,0 +1,38 @@
+package service
+
+import (
+	"avito/models"
+	"fmt"
+	"net/url"
+)
+
+// setTeamChatWebhook задает входящий вебхук чата команды
+func (s *Service) setTeamChatWebhook(req *models.SetTeamChatWebhookRequest) (*models.Team, error) {
+	if req.TeamName == "" {
+		return nil, fmt.Errorf("team name cannot be empty")
+	}
+	if req.URL != "" {
+		u, err := url.Parse(req.URL)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return nil, fmt.Errorf("url must be an absolute http(s) URL")
+		}
+	}
+	if err := s.requireTeam(req.TeamName); err != nil {
+		return nil, err
+	}
+
+	if err := s.repo.SetTeamChatWebhook(req.TeamName, req.URL); err != nil {
+		return nil, fmt.Errorf("failed to set team chat webhook: %w", err)
+	}
+	return s.repo.GetTeam(req.TeamName)
+}
+
+// TeamChatWebhookURL возвращает входящий вебхук чата команды; "" - не задан
+func (s *Service) TeamChatWebhookURL(teamName string) (string, error) {
+	return s.repo.GetTeamChatWebhook(teamName)
+}
+
+// UserChatHandle возвращает ник пользователя в чате (привязка логина chat); "" - не задан
+func (s *Service) UserChatHandle(userID string) (string, error) {
+	return s.repo.GetLoginByUserID(models.ProviderChat, userID)
+}
@@ -0,0 +1,200 @@
+package service
+
+import (
+	"avito/codehost"
+	"avito/models"
+	"context"
+	"fmt"
+	"log"
+	"strconv"
+	"time"
+)
+
+const (
+	// codeHostSyncBatchSize сколько задач синхронизации захватывается за раз
+	codeHostSyncBatchSize = 50
+	// codeHostSyncClaimTimeout на сколько захватываются задачи; не выполненные за это время выдаются снова
+	codeHostSyncClaimTimeout = 5 * time.Minute
+	// codeHostSyncMaxAttempts после стольких неудачных попыток задача получает статус failed
+	codeHostSyncMaxAttempts = 6
+	// codeHostSyncBaseBackoff и codeHostSyncMaxBackoff пауза между попытками: 30s, 1m, 2m... не больше 30 минут
+	codeHostSyncBaseBackoff = 30 * time.Second
+	codeHostSyncMaxBackoff  = 30 * time.Minute
+)
+
+// SetCodeHostClient задает клиент GitHub для синхронизации ревьюверов; nil - синхронизация выключена
+func (s *Service) SetCodeHostClient(client codehost.Client) {
+	s.codehost = client
+}
+
+// queueReviewerSync ставит в очередь изменения ревьюверов PR, пришедшего из GitHub.
+// Вызывается из recordEvent в той же транзакции, что и назначение
+func (s *Service) queueReviewerSync(pullRequestID, eventType string, payload map[string]interface{}) error {
+	if s.codehost == nil {
+		return nil
+	}
+	if _, ok := codehost.ParseGitHubID(pullRequestID); !ok {
+		return nil
+	}
+
+	type syncTask struct{ action, userID string }
+	var tasks []syncTask
+	switch eventType {
+	case models.EventReviewerAssigned:
+		tasks = append(tasks, syncTask{models.SyncRequestReviewer, fmt.Sprint(payload["reviewer_id"])})
+	case models.EventReviewerReplaced:
+		tasks = append(tasks,
+			syncTask{models.SyncRemoveReviewer, fmt.Sprint(payload["old_reviewer_id"])},
+			syncTask{models.SyncRequestReviewer, fmt.Sprint(payload["new_reviewer_id"])})
+	case models.EventReviewerRemoved:
+		tasks = append(tasks, syncTask{models.SyncRemoveReviewer, fmt.Sprint(payload["reviewer_id"])})
+	}
+
+	for _, task := range tasks {
+		if err := s.repo.AddCodeHostSync(pullRequestID, task.action, task.userID); err != nil {
+			return fmt.Errorf("failed to queue reviewer sync: %w", err)
+		}
+	}
+	return nil
+}
+
+// SyncCodeHost отправляет изменения ревьюверов в GitHub, пока очередь не опустеет. Задачи одного PR
+// выполняются по порядку. Временные ошибки повторяются с экспоненциальной паузой; задачи, которые
+// GitHub отклонил или которые исчерпали попытки, получают статус failed и видны в GET /integrations/github/sync
+func (s *Service) SyncCodeHost(ctx context.Context) error {
+	if s.codehost == nil {
+		return nil
+	}
+	for ctx.Err() == nil {
+		n, err := s.syncCodeHostBatch(ctx)
+		if err != nil {
+			return err
+		}
+		if n == 0 {
+			return nil
+		}
+	}
+	return nil
+}
+
+// syncCodeHostBatch захватывает пачку задач в короткой транзакции под блокировкой очереди,
+// выполняет их вне транзакции и сохраняет результат каждой. Возвращает число завершенных
+func (s *Service) syncCodeHostBatch(ctx context.Context) (int, error) {
+	leaseUntil := time.Now().Add(codeHostSyncClaimTimeout)
+	var tasks []*models.CodeHostSyncTask
+	err := s.inTx(func(tx *Service) error {
+		locked, err := tx.repo.TryLockCodeHostSync()
+		if err != nil {
+			return fmt.Errorf("failed to lock reviewer sync queue: %w", err)
+		}
+		if !locked {
+			return nil
+		}
+
+		tasks, err = tx.repo.ClaimCodeHostSync(time.Now(), leaseUntil, codeHostSyncBatchSize)
+		if err != nil {
+			return fmt.Errorf("failed to claim reviewer sync tasks: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	// Невыполненные к истечению захвата задачи выдаются снова
+	ctx, cancel := context.WithDeadline(ctx, leaseUntil)
+	defer cancel()
+
+	finished := 0
+	for _, task := range tasks {
+		if ctx.Err() != nil {
+			break
+		}
+		syncErr := s.syncReviewer(ctx, task)
+
+		now := time.Now()
+		task.Attempts++
+		task.LastError = ""
+		task.NextAttemptAt = nil
+		switch {
+		case syncErr == nil:
+			task.Status = models.SyncDone
+			task.FinishedAt = &now
+			finished++
+		case codehost.IsPermanent(syncErr) || task.Attempts >= codeHostSyncMaxAttempts:
+			log.Printf("Reviewer sync %d (%s %s on %s) failed permanently: %v",
+				task.ID, task.Action, task.UserID, task.PullRequestID, syncErr)
+			task.Status = models.SyncFailed
+			task.LastError = syncErr.Error()
+			task.FinishedAt = &now
+			finished++
+		default:
+			log.Printf("Reviewer sync %d (%s %s on %s) failed (attempt %d): %v",
+				task.ID, task.Action, task.UserID, task.PullRequestID, task.Attempts, syncErr)
+			next := now.Add(retryBackoff(task.Attempts-1, codeHostSyncBaseBackoff, codeHostSyncMaxBackoff))
+			task.LastError = syncErr.Error()
+			task.NextAttemptAt = &next
+		}
+
+		if err := s.repo.UpdateCodeHostSync(task); err != nil {
+			return finished, fmt.Errorf("failed to update reviewer sync task: %w", err)
+		}
+	}
+	return finished, nil
+}
+
+// syncReviewer выполняет одну задачу: находит логин ревьювера и вызывает GitHub
+func (s *Service) syncReviewer(ctx context.Context, task *models.CodeHostSyncTask) error {
+	ref, ok := codehost.ParseGitHubID(task.PullRequestID)
+	if !ok {
+		return codehost.Permanent(fmt.Errorf("pull request ID is not a GitHub reference"))
+	}
+	login, err := s.repo.GetLoginByUserID(models.ProviderGitHub, task.UserID)
+	if err != nil {
+		return fmt.Errorf("failed to resolve github login: %w", err)
+	}
+	if login == "" {
+		return codehost.Permanent(fmt.Errorf("user %s has no github login", task.UserID))
+	}
+
+	if task.Action == models.SyncRemoveReviewer {
+		return s.codehost.RemoveReviewers(ctx, ref, []string{login})
+	}
+	return s.codehost.RequestReviewers(ctx, ref, []string{login})
+}
+
+// ListCodeHostSync возвращает страницу очереди синхронизации ревьюверов от новых задач к старым
+func (s *Service) ListCodeHostSync(filter models.CodeHostSyncFilter, cursor string) (*models.CodeHostSyncListResponse, error) {
+	if filter.Status != "" && filter.Status != models.SyncPending &&
+		filter.Status != models.SyncDone && filter.Status != models.SyncFailed {
+		return nil, fmt.Errorf("invalid status: %s", filter.Status)
+	}
+	if cursor != "" {
+		keys, err := decodeCursor(cursor, 1)
+		if err != nil {
+			return nil, err
+		}
+		beforeID, err := strconv.ParseInt(keys[0], 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid cursor")
+		}
+		filter.BeforeID = beforeID
+	}
+
+	limit := pageLimit(filter.Limit)
+	filter.Limit = limit + 1
+	tasks, err := s.repo.ListCodeHostSync(filter)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list reviewer sync tasks: %w", err)
+	}
+
+	resp := &models.CodeHostSyncListResponse{Tasks: []models.CodeHostSyncTask{}}
+	for i, task := range tasks {
+		if i == limit {
+			resp.NextCursor = encodeCursor(strconv.FormatInt(tasks[i-1].ID, 10))
+			break
+		}
+		resp.Tasks = append(resp.Tasks, *task)
+	}
+	return resp, nil
+}
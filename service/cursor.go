@@ -0,0 +1,44 @@
+package service
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+)
+
+// Курсор пагинации - непрозрачная для клиента строка: base64 от JSON-массива
+// значений ключа сортировки последнего элемента страницы
+
+const (
+	defaultPageLimit = 50
+	maxPageLimit     = 200
+)
+
+func encodeCursor(keys ...string) string {
+	data, _ := json.Marshal(keys)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// decodeCursor разбирает курсор и проверяет количество значений в нем
+func decodeCursor(cursor string, n int) ([]string, error) {
+	data, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return nil, fmt.Errorf("invalid cursor")
+	}
+	var keys []string
+	if err := json.Unmarshal(data, &keys); err != nil || len(keys) != n {
+		return nil, fmt.Errorf("invalid cursor")
+	}
+	return keys, nil
+}
+
+// pageLimit нормализует размер страницы
+func pageLimit(limit int) int {
+	if limit <= 0 {
+		return defaultPageLimit
+	}
+	if limit > maxPageLimit {
+		return maxPageLimit
+	}
+	return limit
+}
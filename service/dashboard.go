@@ -0,0 +1,45 @@
+package service
+
+import (
+	"avito/models"
+	"fmt"
+	"time"
+)
+
+// GetDashboard собирает данные панели нагрузки: команды с участниками и их открытыми ревью,
+// а также OPEN PR старше staleAfter
+func (s *Service) GetDashboard(staleAfter time.Duration) (*models.Dashboard, error) {
+	teams, err := s.repo.ListTeams()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list teams: %w", err)
+	}
+
+	users, err := s.repo.ListUsers(models.UserFilter{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list users: %w", err)
+	}
+	members := map[string][]models.User{}
+	for _, user := range users {
+		for _, teamName := range user.Teams {
+			members[teamName] = append(members[teamName], *user)
+		}
+	}
+
+	stale, err := s.GetStalePRs(staleAfter, "")
+	if err != nil {
+		return nil, err
+	}
+
+	dashboard := &models.Dashboard{
+		Teams:    make([]models.DashboardTeam, len(teams)),
+		StalePRs: stale.PullRequests,
+	}
+	for i, team := range teams {
+		dashboard.Teams[i] = models.DashboardTeam{
+			TeamName:       team.TeamName,
+			ParentTeamName: team.ParentTeamName,
+			Members:        members[team.TeamName],
+		}
+	}
+	return dashboard, nil
+}
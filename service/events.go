@@ -0,0 +1,74 @@
+package service
+
+import (
+	"avito/models"
+	"fmt"
+)
+
+// recordEvent добавляет событие в историю PR от имени текущего исполнителя, ставит изменения
+// ревьюверов в очередь синхронизации с GitHub, готовит уведомления и публикует событие в outbox
+// как pr.<тип события>. Вызывается в той же транзакции, что и изменение PR
+func (s *Service) recordEvent(pullRequestID, eventType string, payload map[string]interface{}) error {
+	if err := s.repo.AddPREvent(pullRequestID, eventType, s.caller.Actor, payload); err != nil {
+		return fmt.Errorf("failed to record %s event: %w", eventType, err)
+	}
+	if err := s.queueReviewerSync(pullRequestID, eventType, payload); err != nil {
+		return err
+	}
+	if err := s.queueEventNotification(pullRequestID, eventType, payload); err != nil {
+		return err
+	}
+	return s.publish("pr."+eventType, models.AuditEntityPR, pullRequestID, map[string]interface{}{
+		"pull_request_id": pullRequestID,
+		"actor":           s.caller.Actor,
+		"data":            payload,
+	})
+}
+
+// replaceReviewer заменяет ревьювера PR и записывает событие reviewer_replaced
+func (s *Service) replaceReviewer(pullRequestID, oldUserID, newUserID, role string) error {
+	return s.inTx(func(tx *Service) error {
+		if err := tx.repo.ReplaceReviewer(pullRequestID, oldUserID, newUserID); err != nil {
+			return fmt.Errorf("failed to replace reviewer: %w", err)
+		}
+		return tx.recordEvent(pullRequestID, models.EventReviewerReplaced, map[string]interface{}{
+			"old_reviewer_id": oldUserID,
+			"new_reviewer_id": newUserID,
+			"role":            role,
+		})
+	})
+}
+
+// removeReviewer снимает ревьювера с PR и записывает событие reviewer_removed
+func (s *Service) removeReviewer(pullRequestID, userID, role string) error {
+	return s.inTx(func(tx *Service) error {
+		if err := tx.repo.RemoveReviewer(pullRequestID, userID); err != nil {
+			return fmt.Errorf("failed to remove reviewer: %w", err)
+		}
+		return tx.recordEvent(pullRequestID, models.EventReviewerRemoved, map[string]interface{}{
+			"reviewer_id": userID,
+			"role":        role,
+		})
+	})
+}
+
+// GetPRTimeline возвращает историю PR в порядке событий
+func (s *Service) GetPRTimeline(pullRequestID string) (*models.PRTimelineResponse, error) {
+	if pullRequestID == "" {
+		return nil, fmt.Errorf("pull request ID cannot be empty")
+	}
+
+	exists, err := s.repo.PRExists(pullRequestID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check PR existence: %w", err)
+	}
+	if !exists {
+		return nil, fmt.Errorf("NOT_FOUND: PR not found")
+	}
+
+	events, err := s.repo.GetPREvents(pullRequestID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get PR events: %w", err)
+	}
+	return &models.PRTimelineResponse{PullRequestID: pullRequestID, Events: events}, nil
+}
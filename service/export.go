@@ -0,0 +1,50 @@
+package service
+
+import (
+	"avito/models"
+	"fmt"
+)
+
+// Выгрузки передают строки в fn по мере чтения из БД. Ошибки валидации возвращаются
+// до первого вызова fn, поэтому вызывающий может ответить ошибкой, пока ничего не записано
+
+// ExportPRs выгружает PR по фильтрам списка PR (курсор и лимит не используются)
+func (s *Service) ExportPRs(filter models.PRFilter, fn func(*models.PRExportRow) error) error {
+	if filter.Status != "" && !isValidStatus(filter.Status) {
+		return fmt.Errorf("invalid status: %s", filter.Status)
+	}
+	filter.Labels = normalizeLabels(filter.Labels)
+	return s.repo.EachPR(filter, fn)
+}
+
+// ExportAssignments выгружает назначения ревьюверов, сделанные в периоде
+func (s *Service) ExportAssignments(filter models.StatsFilter, fn func(*models.AssignmentExportRow) error) error {
+	if err := s.validateStatsFilter(filter); err != nil {
+		return err
+	}
+	return s.repo.EachAssignment(filter, fn)
+}
+
+// ExportUserStats выгружает статистику назначений по пользователям
+func (s *Service) ExportUserStats(filter models.StatsFilter, fn func(models.UserAssignmentStats) error) error {
+	if err := s.validateStatsFilter(filter); err != nil {
+		return err
+	}
+	return s.repo.EachUserAssignmentStats(filter, fn)
+}
+
+// ExportPRStats выгружает статистику назначений по PR
+func (s *Service) ExportPRStats(filter models.StatsFilter, fn func(models.PRAssignmentStats) error) error {
+	if err := s.validateStatsFilter(filter); err != nil {
+		return err
+	}
+	return s.repo.EachPRAssignmentStats(filter, fn)
+}
+
+// ExportTeamStats выгружает статистику PR по командам
+func (s *Service) ExportTeamStats(filter models.StatsFilter, fn func(models.TeamPRStats) error) error {
+	if err := s.validateStatsFilter(filter); err != nil {
+		return err
+	}
+	return s.repo.EachTeamPRStats(filter, fn)
+}
@@ -0,0 +1,223 @@
+package service
+
+import (
+	"avito/models"
+	"fmt"
+	"strings"
+)
+
+// Статусы обработки события внешней системы
+const (
+	integrationCreated  = "created"
+	integrationReopened = "reopened"
+	integrationMerged   = "merged"
+	integrationClosed   = "closed"
+	integrationIgnored  = "ignored"
+)
+
+func isValidProvider(provider string) bool {
+	return provider == models.ProviderGitHub || provider == models.ProviderGitLab || provider == models.ProviderChat
+}
+
+// normalizeLogin приводит логин к нижнему регистру; ники чата (ID пользователей Slack) регистрозависимы
+func normalizeLogin(provider, login string) string {
+	if provider == models.ProviderChat {
+		return login
+	}
+	return strings.ToLower(login)
+}
+
+// SetIdentity привязывает логин внешней системы к пользователю (логины GitHub и GitLab регистронезависимы)
+func (s *Service) SetIdentity(req *models.Identity) (*models.Identity, error) {
+	if req == nil {
+		return nil, fmt.Errorf("request cannot be nil")
+	}
+	if !isValidProvider(req.Provider) {
+		return nil, fmt.Errorf("unknown provider: %s", req.Provider)
+	}
+	if req.Login == "" {
+		return nil, fmt.Errorf("login cannot be empty")
+	}
+	if _, err := s.repo.GetUser(req.UserID); err != nil {
+		return nil, fmt.Errorf("NOT_FOUND: user not found")
+	}
+
+	identity := &models.Identity{
+		Provider:   req.Provider,
+		Login:      normalizeLogin(req.Provider, req.Login),
+		UserID:     req.UserID,
+		ExternalID: req.ExternalID,
+	}
+	if err := s.repo.SetIdentity(identity); err != nil {
+		return nil, fmt.Errorf("failed to set identity: %w", err)
+	}
+	return identity, nil
+}
+
+// DeleteIdentity удаляет привязку логина
+func (s *Service) DeleteIdentity(req *models.DeleteIdentityRequest) error {
+	if req == nil {
+		return fmt.Errorf("request cannot be nil")
+	}
+	deleted, err := s.repo.DeleteIdentity(req.Provider, normalizeLogin(req.Provider, req.Login))
+	if err != nil {
+		return fmt.Errorf("failed to delete identity: %w", err)
+	}
+	if !deleted {
+		return fmt.Errorf("NOT_FOUND: identity not found")
+	}
+	return nil
+}
+
+// ListIdentities возвращает привязки логинов; пустой provider - всех систем
+func (s *Service) ListIdentities(provider string) ([]models.Identity, error) {
+	if provider != "" && !isValidProvider(provider) {
+		return nil, fmt.Errorf("unknown provider: %s", provider)
+	}
+	identities, err := s.repo.ListIdentities(provider)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list identities: %w", err)
+	}
+	return identities, nil
+}
+
+// resolveLogin возвращает пользователя, привязанного к логину внешней системы
+func (s *Service) resolveLogin(provider, login string) (string, error) {
+	userID, err := s.repo.GetUserIDByLogin(provider, login)
+	if err != nil {
+		return "", fmt.Errorf("failed to resolve %s login: %w", provider, err)
+	}
+	if userID == "" {
+		return "", fmt.Errorf("UNKNOWN_USER: %s login %q is not mapped to a user", provider, login)
+	}
+	return userID, nil
+}
+
+// resolveAuthor возвращает автора PR по логину, а если логин неизвестен - по ID внешней системы.
+// Когда известны оба, ID запоминается в привязке логина, чтобы находить автора по событиям других пользователей
+func (s *Service) resolveAuthor(ev *models.CodeHostPREvent) (string, error) {
+	if ev.AuthorLogin != "" {
+		userID, err := s.resolveLogin(ev.Provider, ev.AuthorLogin)
+		if err != nil {
+			return "", err
+		}
+		if ev.AuthorExternalID != "" {
+			if err := s.repo.SetIdentityExternalID(ev.Provider, ev.AuthorLogin, ev.AuthorExternalID); err != nil {
+				return "", fmt.Errorf("failed to save %s user ID: %w", ev.Provider, err)
+			}
+		}
+		return userID, nil
+	}
+	if ev.AuthorExternalID == "" {
+		return "", fmt.Errorf("UNKNOWN_USER: %s event has no author", ev.Provider)
+	}
+
+	userID, err := s.repo.GetUserIDByExternalID(ev.Provider, ev.AuthorExternalID)
+	if err != nil {
+		return "", fmt.Errorf("failed to resolve %s user ID: %w", ev.Provider, err)
+	}
+	if userID == "" {
+		return "", fmt.Errorf("UNKNOWN_USER: %s user ID %s is not mapped to a user", ev.Provider, ev.AuthorExternalID)
+	}
+	return userID, nil
+}
+
+// IngestPREvent применяет событие PR из внешней системы: opened/reopened создают PR или возвращают
+// в работу закрытый, merged и closed мержат и закрывают его. Черновики не создаются, пока не готовы к ревью.
+// Повторная доставка события не считается ошибкой: уже известный PR при открытии, неизвестный
+// при мерже и закрытии и закрытый при мерже пропускаются. Прочие действия игнорируются
+func (s *Service) IngestPREvent(ev *models.CodeHostPREvent) (*models.IntegrationResult, error) {
+	if ev == nil {
+		return nil, fmt.Errorf("event cannot be nil")
+	}
+	result := &models.IntegrationResult{PullRequestID: ev.PullRequestID}
+	ignore := func(reason string) (*models.IntegrationResult, error) {
+		result.Status, result.Reason = integrationIgnored, reason
+		return result, nil
+	}
+
+	switch ev.Action {
+	case models.CodeHostOpened, models.CodeHostReopened:
+		if ev.Draft {
+			return ignore("pull request is a draft")
+		}
+		exists, err := s.repo.PRExists(ev.PullRequestID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to check PR existence: %w", err)
+		}
+		if exists {
+			pr, err := s.GetPR(ev.PullRequestID)
+			if err != nil {
+				return nil, err
+			}
+			if pr.Status != "CLOSED" {
+				return ignore("pull request is already tracked")
+			}
+			if _, err := s.ReopenPR(ev.PullRequestID); err != nil {
+				return nil, err
+			}
+			result.Status = integrationReopened
+			return result, nil
+		}
+
+		authorID, err := s.resolveAuthor(ev)
+		if err != nil {
+			return nil, err
+		}
+		_, err = s.CreatePR(&models.CreatePRRequest{
+			PullRequestID:   ev.PullRequestID,
+			PullRequestName: ev.Title,
+			AuthorID:        authorID,
+			Labels:          ev.Labels,
+			Additions:       ev.Additions,
+			Deletions:       ev.Deletions,
+			FilesChanged:    ev.FilesChanged,
+		})
+		if reason, ok := ignoredIngestError(err); ok {
+			return ignore(reason)
+		}
+		if err != nil {
+			return nil, err
+		}
+		result.Status = integrationCreated
+	case models.CodeHostMerged:
+		_, err := s.MergePR(ev.PullRequestID)
+		if reason, ok := ignoredIngestError(err); ok {
+			return ignore(reason)
+		}
+		if err != nil {
+			return nil, err
+		}
+		result.Status = integrationMerged
+	case models.CodeHostClosed:
+		_, err := s.ClosePR(ev.PullRequestID, "closed_in_"+ev.Provider)
+		if reason, ok := ignoredIngestError(err); ok {
+			return ignore(reason)
+		}
+		if err != nil {
+			return nil, err
+		}
+		result.Status = integrationClosed
+	default:
+		return ignore(fmt.Sprintf("action %q is not handled", ev.Action))
+	}
+	return result, nil
+}
+
+// ignoredIngestError возвращает причину пропуска события, если ошибка сервиса означает, что PR
+// уже в нужном состоянии или не отслеживается: повторная доставка не должна давать ошибку,
+// иначе внешняя система будет присылать событие снова
+func ignoredIngestError(err error) (string, bool) {
+	if err == nil {
+		return "", false
+	}
+	switch code, _, _ := strings.Cut(err.Error(), ":"); code {
+	case "NOT_FOUND":
+		return "pull request is not tracked", true
+	case "PR_EXISTS":
+		return "pull request is already tracked", true
+	case "PR_CLOSED":
+		return "pull request is closed", true
+	}
+	return "", false
+}
@@ -0,0 +1,28 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+)
+
+func TestIgnoredIngestError(t *testing.T) {
+	tests := []struct {
+		err    error
+		reason string
+		ok     bool
+	}{
+		{nil, "", false},
+		{fmt.Errorf("PR_CLOSED: cannot merge closed PR"), "pull request is closed", true},
+		{fmt.Errorf("NOT_FOUND: PR not found"), "pull request is not tracked", true},
+		{fmt.Errorf("PR_EXISTS: PR id already exists"), "pull request is already tracked", true},
+		{fmt.Errorf("UNKNOWN_USER: github user octo-dev is not mapped to a user"), "", false},
+		{errors.New("failed to merge PR: connection refused"), "", false},
+	}
+	for _, tt := range tests {
+		reason, ok := ignoredIngestError(tt.err)
+		if reason != tt.reason || ok != tt.ok {
+			t.Errorf("ignoredIngestError(%v) = %q, %v; want %q, %v", tt.err, reason, ok, tt.reason, tt.ok)
+		}
+	}
+}
@@ -0,0 +1,364 @@
+package service
+
+import (
+	"avito/models"
+	"avito/repository"
+	"fmt"
+)
+
+// addTeamMember добавляет пользователя в существующую команду, при необходимости создавая его
+func (s *Service) addTeamMember(req *models.AddTeamMemberRequest) (*models.Team, error) {
+	if req == nil {
+		return nil, fmt.Errorf("request cannot be nil")
+	}
+	if req.TeamName == "" {
+		return nil, fmt.Errorf("team name cannot be empty")
+	}
+	if req.UserID == "" {
+		return nil, fmt.Errorf("user ID cannot be empty")
+	}
+
+	err := s.inTx(func(tx *Service) error {
+		if err := tx.requireTeam(req.TeamName); err != nil {
+			return err
+		}
+
+		user, err := tx.repo.GetUser(req.UserID)
+		if err != nil {
+			// Пользователь новый: создаем его
+			if req.Username == "" {
+				return fmt.Errorf("username is required for a new user")
+			}
+			isActive := true
+			if req.IsActive != nil {
+				isActive = *req.IsActive
+			}
+			user = &models.User{UserID: req.UserID, Username: req.Username, IsActive: isActive}
+		} else {
+			if req.Username != "" {
+				user.Username = req.Username
+			}
+			if req.IsActive != nil {
+				user.IsActive = *req.IsActive
+			}
+		}
+
+		if err := tx.saveUser(user.UserID, user.Username, user.IsActive, req.TeamName); err != nil {
+			return err
+		}
+		if err := tx.repo.AddUserToTeam(req.TeamName, req.UserID); err != nil {
+			return fmt.Errorf("failed to add user to team: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return s.repo.GetTeam(req.TeamName)
+}
+
+// removeTeamMember удаляет пользователя из команды, при необходимости переназначая его открытые ревью
+func (s *Service) removeTeamMember(req *models.RemoveTeamMemberRequest) (*models.RemoveTeamMemberResponse, error) {
+	if req == nil {
+		return nil, fmt.Errorf("request cannot be nil")
+	}
+	if req.TeamName == "" {
+		return nil, fmt.Errorf("team name cannot be empty")
+	}
+	if req.UserID == "" {
+		return nil, fmt.Errorf("user ID cannot be empty")
+	}
+	mode, err := openReviewsMode(req.OpenReviews)
+	if err != nil {
+		return nil, err
+	}
+
+	var reassigned []models.ReviewReassignment
+	err = s.inTx(func(tx *Service) error {
+		var err error
+		reassigned, err = tx.leaveTeam(req.TeamName, req.UserID, mode)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	team, err := s.repo.GetTeam(req.TeamName)
+	if err != nil {
+		return nil, err
+	}
+	return &models.RemoveTeamMemberResponse{Team: *team, Reassigned: reassigned}, nil
+}
+
+// moveTeamMember переводит пользователя из одной команды в другую
+func (s *Service) moveTeamMember(req *models.MoveTeamMemberRequest) (*models.MoveTeamMemberResponse, error) {
+	if req == nil {
+		return nil, fmt.Errorf("request cannot be nil")
+	}
+	if req.UserID == "" {
+		return nil, fmt.Errorf("user ID cannot be empty")
+	}
+	if req.FromTeam == "" || req.ToTeam == "" {
+		return nil, fmt.Errorf("from_team and to_team cannot be empty")
+	}
+	if req.FromTeam == req.ToTeam {
+		return nil, fmt.Errorf("from_team and to_team must differ")
+	}
+	mode, err := openReviewsMode(req.OpenReviews)
+	if err != nil {
+		return nil, err
+	}
+
+	var reassigned []models.ReviewReassignment
+	err = s.inTx(func(tx *Service) error {
+		if err := tx.requireTeam(req.ToTeam); err != nil {
+			return err
+		}
+
+		var err error
+		reassigned, err = tx.leaveTeam(req.FromTeam, req.UserID, mode)
+		if err != nil {
+			return err
+		}
+
+		if err := tx.repo.AddUserToTeam(req.ToTeam, req.UserID); err != nil {
+			return fmt.Errorf("failed to add user to team: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	fromTeam, err := s.repo.GetTeam(req.FromTeam)
+	if err != nil {
+		return nil, err
+	}
+	toTeam, err := s.repo.GetTeam(req.ToTeam)
+	if err != nil {
+		return nil, err
+	}
+	return &models.MoveTeamMemberResponse{FromTeam: *fromTeam, ToTeam: *toTeam, Reassigned: reassigned}, nil
+}
+
+// leaveTeam удаляет пользователя из команды; в режиме reassign его открытые ревью
+// на PR авторов этой команды переходят другим ее участникам
+func (s *Service) leaveTeam(teamName, userID, mode string) ([]models.ReviewReassignment, error) {
+	if err := s.requireTeam(teamName); err != nil {
+		return nil, err
+	}
+
+	member, err := s.repo.IsTeamMember(teamName, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check team membership: %w", err)
+	}
+	if !member {
+		return nil, fmt.Errorf("NOT_FOUND: user is not a member of team %s", teamName)
+	}
+
+	reassigned := []models.ReviewReassignment{}
+	if mode == models.OpenReviewsReassign {
+		prIDs, err := s.repo.GetOpenReviewsInTeam(userID, teamName)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get open reviews: %w", err)
+		}
+		for _, prID := range prIDs {
+			newUserID, err := s.reassignWithinTeam(prID, userID, teamName)
+			if err != nil {
+				return nil, err
+			}
+			reassigned = append(reassigned, models.ReviewReassignment{
+				PullRequestID: prID,
+				OldUserID:     userID,
+				NewUserID:     newUserID,
+			})
+		}
+	}
+
+	if err := s.repo.RemoveUserFromTeam(teamName, userID); err != nil {
+		return nil, fmt.Errorf("failed to remove user from team: %w", err)
+	}
+	return reassigned, nil
+}
+
+// reassignWithinTeam заменяет ревьювера PR на активного участника указанной команды
+func (s *Service) reassignWithinTeam(pullRequestID, oldUserID, teamName string) (string, error) {
+	pr, err := s.repo.GetPR(pullRequestID)
+	if err != nil {
+		return "", fmt.Errorf("NOT_FOUND: PR not found")
+	}
+
+	members, err := s.repo.GetActiveTeamMembers(teamName)
+	if err != nil {
+		return "", fmt.Errorf("failed to get team members: %w", err)
+	}
+	candidates, err := s.replacementCandidates(pr, oldUserID, reviewerRole(pr, oldUserID), members)
+	if err != nil {
+		return "", err
+	}
+	if len(candidates) == 0 {
+		return "", fmt.Errorf("NO_CANDIDATE: no active replacement candidate in team %s for PR %s", teamName, pullRequestID)
+	}
+
+	newUserID := repository.SelectRandomReviewers(candidates, 1)[0].UserID
+	if err := s.replaceReviewer(pullRequestID, oldUserID, newUserID, reviewerRole(pr, oldUserID)); err != nil {
+		return "", err
+	}
+	return newUserID, nil
+}
+
+// saveUser создает или обновляет пользователя команды teamName; смена активности
+// существующего пользователя публикует user.activated или user.deactivated.
+// Уволенного пользователя нельзя вернуть в команду
+func (s *Service) saveUser(userID, username string, isActive bool, teamName string) error {
+	before, err := s.repo.GetUser(userID)
+	exists := err == nil
+	if exists && before.DepartedAt != nil {
+		return departedUserError(userID)
+	}
+	if err := s.repo.CreateOrUpdateUser(userID, username, isActive); err != nil {
+		return fmt.Errorf("failed to create/update user %s: %w", userID, err)
+	}
+	if !exists || before.IsActive == isActive {
+		return nil
+	}
+	return s.publishUserActivity(userID, teamName, isActive)
+}
+
+func departedUserError(userID string) error {
+	return fmt.Errorf("USER_DEPARTED: departed user %s cannot be added to a team", userID)
+}
+
+func (s *Service) requireTeam(teamName string) error {
+	exists, err := s.repo.TeamExists(teamName)
+	if err != nil {
+		return fmt.Errorf("failed to check team existence: %w", err)
+	}
+	if !exists {
+		return fmt.Errorf("NOT_FOUND: team %s not found", teamName)
+	}
+	return nil
+}
+
+func openReviewsMode(mode string) (string, error) {
+	switch mode {
+	case "":
+		return models.OpenReviewsKeep, nil
+	case models.OpenReviewsKeep, models.OpenReviewsReassign:
+		return mode, nil
+	}
+	return "", fmt.Errorf("invalid open_reviews: %s (must be keep or reassign)", mode)
+}
+
+// upsertTeam приводит команду к переданному описанию: создает команду и недостающих
+// пользователей, обновляет username/is_active и удаляет не перечисленных участников.
+// Повторный вызов с теми же данными ничего не меняет
+func (s *Service) upsertTeam(req *models.UpsertTeamRequest) (*models.UpsertTeamResponse, error) {
+	if req == nil {
+		return nil, fmt.Errorf("request cannot be nil")
+	}
+	if req.TeamName == "" {
+		return nil, fmt.Errorf("team name cannot be empty")
+	}
+	mode, err := openReviewsMode(req.OpenReviews)
+	if err != nil {
+		return nil, err
+	}
+
+	desired := make(map[string]bool, len(req.Members))
+	for _, m := range req.Members {
+		if m.UserID == "" {
+			return nil, fmt.Errorf("member user ID cannot be empty")
+		}
+		if desired[m.UserID] {
+			return nil, fmt.Errorf("duplicate member %s", m.UserID)
+		}
+		desired[m.UserID] = true
+	}
+
+	diff := models.TeamDiff{
+		Added:      []string{},
+		Updated:    []string{},
+		Removed:    []string{},
+		Unchanged:  []string{},
+		Reassigned: []models.ReviewReassignment{},
+	}
+	err = s.inTx(func(tx *Service) error {
+		exists, err := tx.repo.TeamExists(req.TeamName)
+		if err != nil {
+			return fmt.Errorf("failed to check team existence: %w", err)
+		}
+		if !exists {
+			if err := tx.repo.CreateTeam(req.TeamName); err != nil {
+				return fmt.Errorf("failed to create team: %w", err)
+			}
+			diff.TeamCreated = true
+		}
+
+		if req.ParentTeamName != nil {
+			if err := tx.checkParent(req.TeamName, *req.ParentTeamName); err != nil {
+				return err
+			}
+			if err := tx.repo.SetTeamParent(req.TeamName, *req.ParentTeamName); err != nil {
+				return fmt.Errorf("failed to set parent team: %w", err)
+			}
+		}
+
+		current, err := tx.repo.GetTeam(req.TeamName)
+		if err != nil {
+			return err
+		}
+		isMember := make(map[string]bool, len(current.Members))
+		for _, m := range current.Members {
+			isMember[m.UserID] = true
+		}
+
+		for _, m := range req.Members {
+			user, err := tx.repo.GetUser(m.UserID)
+			if err == nil && user.DepartedAt != nil {
+				return departedUserError(m.UserID)
+			}
+			changed := err != nil || user.Username != m.Username || user.IsActive != m.IsActive
+			if changed {
+				if err := tx.saveUser(m.UserID, m.Username, m.IsActive, req.TeamName); err != nil {
+					return err
+				}
+			}
+
+			switch {
+			case !isMember[m.UserID]:
+				if err := tx.repo.AddUserToTeam(req.TeamName, m.UserID); err != nil {
+					return fmt.Errorf("failed to add user to team: %w", err)
+				}
+				diff.Added = append(diff.Added, m.UserID)
+			case changed:
+				diff.Updated = append(diff.Updated, m.UserID)
+			default:
+				diff.Unchanged = append(diff.Unchanged, m.UserID)
+			}
+		}
+
+		for _, m := range current.Members {
+			if desired[m.UserID] {
+				continue
+			}
+			reassigned, err := tx.leaveTeam(req.TeamName, m.UserID, mode)
+			if err != nil {
+				return err
+			}
+			diff.Removed = append(diff.Removed, m.UserID)
+			diff.Reassigned = append(diff.Reassigned, reassigned...)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	team, err := s.repo.GetTeam(req.TeamName)
+	if err != nil {
+		return nil, err
+	}
+	return &models.UpsertTeamResponse{Team: *team, Diff: diff}, nil
+}
@@ -0,0 +1,234 @@
+package service
+
+import (
+	"avito/models"
+	"avito/notifier"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+)
+
+// SendDigests отправляет каждому ревьюверу сводку его открытых ревью
+func (s *Service) SendDigests(ctx context.Context) error {
+	reviewerIDs, err := s.repo.GetReviewersWithOpenReviews()
+	if err != nil {
+		return fmt.Errorf("failed to get reviewers: %w", err)
+	}
+
+	for _, reviewerID := range reviewerIDs {
+		items, err := s.repo.GetPRsByReviewer(models.ReviewFilter{
+			ReviewerID: reviewerID,
+			Status:     "OPEN",
+			Sort:       models.ReviewSortPriority,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to get PRs for %s: %w", reviewerID, err)
+		}
+
+		// Ревью с уже вынесенным вердиктом не ждут реакции ревьювера
+		pending := []models.PullRequestShort{}
+		for _, item := range items {
+			if item.Verdict == "" {
+				pending = append(pending, item.PullRequestShort)
+			}
+		}
+		if len(pending) == 0 {
+			continue
+		}
+
+		lines := make([]string, len(pending))
+		for i, pr := range pending {
+			lines[i] = fmt.Sprintf("- %s (%s), author %s", pr.PullRequestName, pr.PullRequestID, pr.AuthorID)
+		}
+
+		msg := notifier.Message{
+			Kind:         notifier.KindDigest,
+			UserID:       reviewerID,
+			Subject:      fmt.Sprintf("%d pull request(s) waiting for your review", len(pending)),
+			Text:         strings.Join(lines, "\n"),
+			PullRequests: pending,
+			CreatedAt:    time.Now(),
+		}
+		if err := s.notifier.Notify(ctx, msg); err != nil {
+			// Ошибка доставки одному ревьюверу не должна блокировать остальных
+			log.Printf("Error sending digest to %s: %v", reviewerID, err)
+		}
+	}
+
+	return nil
+}
+
+// SendReminders напоминает о ревью, назначенных больше threshold назад.
+// Напоминание по каждому назначению отправляется один раз
+func (s *Service) SendReminders(ctx context.Context, threshold time.Duration) error {
+	if threshold <= 0 {
+		return fmt.Errorf("reminder threshold must be positive")
+	}
+
+	reviews, err := s.repo.GetOverdueReviews(time.Now().Add(-threshold))
+	if err != nil {
+		return fmt.Errorf("failed to get overdue reviews: %w", err)
+	}
+
+	for _, review := range reviews {
+		age := time.Since(review.AssignedAt).Round(time.Minute)
+		msg := notifier.Message{
+			Kind:         notifier.KindReminder,
+			UserID:       review.ReviewerID,
+			Subject:      fmt.Sprintf("Review of %s is waiting for %s", review.PR.PullRequestID, age),
+			Text:         fmt.Sprintf("%s (%s), author %s", review.PR.PullRequestName, review.PR.PullRequestID, review.PR.AuthorID),
+			PullRequests: []models.PullRequestShort{review.PR},
+			TeamName:     s.authorTeam(review.PR.AuthorID),
+			CreatedAt:    time.Now(),
+		}
+		if err := s.notifier.Notify(ctx, msg); err != nil {
+			log.Printf("Error sending reminder to %s: %v", review.ReviewerID, err)
+			continue
+		}
+
+		if err := s.repo.MarkReviewReminded(review.PR.PullRequestID, review.ReviewerID); err != nil {
+			return fmt.Errorf("failed to mark reminder as sent: %w", err)
+		}
+	}
+
+	return nil
+}
+
+const (
+	// eventNotifyBatchSize сколько уведомлений о событиях захватывается за раз
+	eventNotifyBatchSize = 50
+	// eventNotifyClaimTimeout на сколько захватываются уведомления; не отправленные за это время выдаются снова
+	eventNotifyClaimTimeout = 5 * time.Minute
+	// eventNotifyMaxAttempts после стольких неудачных попыток уведомление отбрасывается
+	eventNotifyMaxAttempts = 5
+	// eventNotifyBaseBackoff и eventNotifyMaxBackoff пауза между попытками: 30s, 1m, 2m... не больше 10 минут
+	eventNotifyBaseBackoff = 30 * time.Second
+	eventNotifyMaxBackoff  = 10 * time.Minute
+)
+
+// queueEventNotification ставит в очередь уведомление о назначении, переназначении ревьювера или merge PR.
+// Вызывается из recordEvent в той же транзакции, поэтому уведомление уходит только о зафиксированном изменении
+func (s *Service) queueEventNotification(pullRequestID, eventType string, payload map[string]interface{}) error {
+	if s.events == nil {
+		return nil
+	}
+
+	var msg notifier.Message
+	switch eventType {
+	case models.EventReviewerAssigned:
+		msg.Kind = notifier.KindAssigned
+		msg.UserID = fmt.Sprint(payload["reviewer_id"])
+	case models.EventReviewerReplaced:
+		msg.Kind = notifier.KindReassigned
+		msg.UserID = fmt.Sprint(payload["new_reviewer_id"])
+		msg.PreviousUserID = fmt.Sprint(payload["old_reviewer_id"])
+	case models.EventMerged:
+		msg.Kind = notifier.KindMerged
+	default:
+		return nil
+	}
+
+	pr, err := s.repo.GetPR(pullRequestID)
+	if err != nil {
+		return fmt.Errorf("failed to get PR for notification: %w", err)
+	}
+	short := models.PullRequestShort{
+		PullRequestID:   pr.PullRequestID,
+		PullRequestName: pr.PullRequestName,
+		AuthorID:        pr.AuthorID,
+		Status:          pr.Status,
+		Priority:        pr.Priority,
+		Labels:          pr.Labels,
+	}
+	msg.PullRequests = []models.PullRequestShort{short}
+	msg.TeamName = s.authorTeam(pr.AuthorID)
+	msg.CreatedAt = time.Now()
+
+	switch msg.Kind {
+	case notifier.KindAssigned:
+		msg.Subject = fmt.Sprintf("You were assigned to review %s", pr.PullRequestID)
+	case notifier.KindReassigned:
+		msg.Subject = fmt.Sprintf("You were assigned to review %s instead of %s", pr.PullRequestID, msg.PreviousUserID)
+	case notifier.KindMerged:
+		msg.UserID = pr.AuthorID
+		msg.Subject = fmt.Sprintf("Pull request %s was merged", pr.PullRequestID)
+	}
+	msg.Text = fmt.Sprintf("%s (%s), author %s", pr.PullRequestName, pr.PullRequestID, pr.AuthorID)
+
+	if err := s.repo.AddEventNotification(msg); err != nil {
+		return fmt.Errorf("failed to queue notification: %w", err)
+	}
+	return nil
+}
+
+// SendEventNotifications отправляет накопившиеся уведомления о событиях PR, пока очередь не опустеет.
+// Неудачная отправка повторяется с экспоненциальной паузой; после eventNotifyMaxAttempts попыток
+// уведомление отбрасывается с записью в лог
+func (s *Service) SendEventNotifications(ctx context.Context) error {
+	if s.events == nil {
+		return nil
+	}
+	for ctx.Err() == nil {
+		n, err := s.sendEventNotificationBatch(ctx)
+		if err != nil {
+			return err
+		}
+		if n < eventNotifyBatchSize {
+			return nil
+		}
+	}
+	return nil
+}
+
+// sendEventNotificationBatch захватывает пачку уведомлений, отправляет их вне транзакции
+// и возвращает размер пачки
+func (s *Service) sendEventNotificationBatch(ctx context.Context) (int, error) {
+	leaseUntil := time.Now().Add(eventNotifyClaimTimeout)
+	queued, err := s.repo.ClaimEventNotifications(time.Now(), leaseUntil, eventNotifyBatchSize)
+	if err != nil {
+		return 0, fmt.Errorf("failed to claim notifications: %w", err)
+	}
+
+	ctx, cancel := context.WithDeadline(ctx, leaseUntil)
+	defer cancel()
+
+	for _, q := range queued {
+		if ctx.Err() != nil {
+			break
+		}
+
+		var msg notifier.Message
+		sendErr := json.Unmarshal(q.Message, &msg)
+		if sendErr == nil {
+			sendErr = s.events.Notify(ctx, msg)
+		}
+
+		switch {
+		case sendErr == nil:
+			err = s.repo.DeleteEventNotification(q.ID)
+		case q.Attempts+1 >= eventNotifyMaxAttempts:
+			log.Printf("Dropping %s notification %d to %s after %d attempts: %v", msg.Kind, q.ID, msg.UserID, q.Attempts+1, sendErr)
+			err = s.repo.DeleteEventNotification(q.ID)
+		default:
+			log.Printf("Error sending %s notification %d to %s (attempt %d): %v", msg.Kind, q.ID, msg.UserID, q.Attempts+1, sendErr)
+			next := time.Now().Add(retryBackoff(q.Attempts, eventNotifyBaseBackoff, eventNotifyMaxBackoff))
+			err = s.repo.RetryEventNotification(q.ID, sendErr.Error(), next)
+		}
+		if err != nil {
+			return len(queued), fmt.Errorf("failed to update notification: %w", err)
+		}
+	}
+	return len(queued), nil
+}
+
+// authorTeam возвращает команду автора PR для выбора канала уведомлений; "" - автор вне команд
+func (s *Service) authorTeam(authorID string) string {
+	teamName, err := s.repo.GetUserTeamName(authorID)
+	if err != nil {
+		return ""
+	}
+	return teamName
+}
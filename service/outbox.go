@@ -0,0 +1,232 @@
+package service
+
+import (
+	"avito/models"
+	"avito/outbox"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"time"
+)
+
+const (
+	// outboxBatchSize сколько событий диспетчер захватывает за раз
+	outboxBatchSize = 100
+	// outboxClaimTimeout на сколько захватываются события; не доставленные за это время выдаются снова
+	outboxClaimTimeout = 5 * time.Minute
+	// outboxMaxBackoff предельная пауза между попытками доставки события
+	outboxMaxBackoff = 10 * time.Minute
+)
+
+// SetOutboxSink задает получателя событий outbox
+func (s *Service) SetOutboxSink(sink outbox.Sink) {
+	if sink == nil {
+		sink = outbox.NopSink{}
+	}
+	s.outbox = sink
+}
+
+// publish добавляет событие в outbox и ставит его в очередь доставки подходящим вебхукам.
+// Вызывается в той же транзакции, что и изменение, поэтому событие публикуется тогда
+// и только тогда, когда изменение зафиксировано
+func (s *Service) publish(eventType, aggregateType, aggregateID string, payload interface{}) error {
+	ev, err := s.repo.AddOutboxEvent(eventType, aggregateType, aggregateID, payload)
+	if err != nil {
+		return fmt.Errorf("failed to write %s to outbox: %w", eventType, err)
+	}
+	if err := s.repo.AddWebhookDeliveries(ev); err != nil {
+		return fmt.Errorf("failed to queue webhook deliveries: %w", err)
+	}
+	return nil
+}
+
+// publishStateChange публикует события по состоянию сущности до и после изменения:
+// team.changed для команд и user.activated/user.deactivated при смене активности пользователя
+func (s *Service) publishStateChange(entityType, beforeID, afterID string, before, after interface{}) error {
+	switch entityType {
+	case models.AuditEntityTeam:
+		beforeJSON, err := json.Marshal(before)
+		if err != nil {
+			return err
+		}
+		afterJSON, err := json.Marshal(after)
+		if err != nil {
+			return err
+		}
+		if bytes.Equal(beforeJSON, afterJSON) {
+			return nil
+		}
+		payload := map[string]interface{}{"team_name": beforeID, "team": after}
+		if afterID != beforeID {
+			payload["new_team_name"] = afterID
+		}
+		return s.publish(models.OutboxTeamChanged, entityType, beforeID, payload)
+	case models.AuditEntityUser:
+		b, _ := before.(*models.User)
+		a, _ := after.(*models.User)
+		if b == nil || a == nil || b.IsActive == a.IsActive {
+			return nil
+		}
+		return s.publishUserActivity(a.UserID, a.TeamName, a.IsActive)
+	}
+	return nil
+}
+
+// publishUserActivity публикует user.activated или user.deactivated
+func (s *Service) publishUserActivity(userID, teamName string, isActive bool) error {
+	eventType := models.OutboxUserDeactivated
+	if isActive {
+		eventType = models.OutboxUserActivated
+	}
+	return s.publish(eventType, models.AuditEntityUser, userID, map[string]interface{}{
+		"user_id":   userID,
+		"team_name": teamName,
+		"actor":     s.caller.Actor,
+	})
+}
+
+// withTeamEvents выполняет fn и публикует team.changed для команд пользователя и extraTeams,
+// состав которых изменился. Нужен операциям над пользователем, которые затрагивают и его команды
+func (s *Service) withTeamEvents(userID string, extraTeams []string, fn func() error) error {
+	teamNames, err := s.repo.GetUserTeamNames(userID)
+	if err != nil {
+		return fmt.Errorf("failed to get user teams: %w", err)
+	}
+	teamNames = append(teamNames, extraTeams...)
+
+	var names []string
+	before := make(map[string]interface{}, len(teamNames))
+	for _, name := range teamNames {
+		if _, ok := before[name]; ok {
+			continue
+		}
+		snap, err := s.snapshot(models.AuditEntityTeam, name)
+		if err != nil {
+			return fmt.Errorf("failed to capture team state: %w", err)
+		}
+		names = append(names, name)
+		before[name] = snap
+	}
+
+	if err := fn(); err != nil {
+		return err
+	}
+
+	for _, name := range names {
+		after, err := s.snapshot(models.AuditEntityTeam, name)
+		if err != nil {
+			return fmt.Errorf("failed to capture team state: %w", err)
+		}
+		if err := s.publishStateChange(models.AuditEntityTeam, name, name, before[name], after); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// DispatchOutbox доставляет накопившиеся события получателю. События одного агрегата
+// отправляются строго по порядку: следующее ждет, пока не доставлено предыдущее.
+// Неудачная попытка повторяется с экспоненциальной паузой
+func (s *Service) DispatchOutbox(ctx context.Context) error {
+	for ctx.Err() == nil {
+		delivered, err := s.dispatchOutboxBatch(ctx)
+		if err != nil {
+			return err
+		}
+		if delivered == 0 {
+			return nil
+		}
+	}
+	return nil
+}
+
+// dispatchOutboxBatch захватывает пачку событий в короткой транзакции под блокировкой outbox,
+// доставляет их вне транзакции и отмечает результат каждого. Возвращает число доставленных
+func (s *Service) dispatchOutboxBatch(ctx context.Context) (int, error) {
+	leaseUntil := time.Now().Add(outboxClaimTimeout)
+	var events []models.OutboxEvent
+	err := s.inTx(func(tx *Service) error {
+		locked, err := tx.repo.TryLockOutbox()
+		if err != nil {
+			return fmt.Errorf("failed to lock outbox: %w", err)
+		}
+		if !locked {
+			return nil
+		}
+
+		events, err = tx.repo.ClaimOutboxEvents(time.Now(), leaseUntil, outboxBatchSize)
+		if err != nil {
+			return fmt.Errorf("failed to claim outbox events: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	// Не начинаем отправку после истечения захвата: событие уже может взять другой диспетчер.
+	// Неотправленные события выдаются снова по истечении захвата
+	ctx, cancel := context.WithDeadline(ctx, leaseUntil)
+	defer cancel()
+
+	return deliverOutboxEvents(ctx, s.outbox, s.repo, events)
+}
+
+// outboxMarker сохраняет результат попытки доставки события outbox
+type outboxMarker interface {
+	MarkOutboxDelivered(id int64, deliveredAt time.Time) error
+	MarkOutboxFailed(id int64, lastError string, nextAttemptAt time.Time) error
+}
+
+// deliverOutboxEvents отправляет захваченные события по порядку и отмечает результат каждого:
+// неудачное откладывается по retryBackoff, остальные отправляются дальше. Возвращает число доставленных
+func deliverOutboxEvents(ctx context.Context, sink outbox.Sink, marker outboxMarker, events []models.OutboxEvent) (int, error) {
+	delivered := 0
+	for _, ev := range events {
+		if ctx.Err() != nil {
+			break
+		}
+		if err := sink.Publish(ctx, ev); err != nil {
+			log.Printf("Failed to publish outbox event %d (%s, attempt %d): %v", ev.ID, ev.Type, ev.Attempts+1, err)
+			if err := marker.MarkOutboxFailed(ev.ID, err.Error(), time.Now().Add(retryBackoff(ev.Attempts, time.Second, outboxMaxBackoff))); err != nil {
+				return delivered, fmt.Errorf("failed to update outbox event: %w", err)
+			}
+			continue
+		}
+		if err := marker.MarkOutboxDelivered(ev.ID, time.Now()); err != nil {
+			return delivered, fmt.Errorf("failed to update outbox event: %w", err)
+		}
+		delivered++
+	}
+	return delivered, nil
+}
+
+// retryBackoff пауза перед следующей попыткой после attempts неудачных: base, 2*base, 4*base... но не больше max
+func retryBackoff(attempts int, base, max time.Duration) time.Duration {
+	if attempts >= 30 {
+		return max
+	}
+	d := base << uint(attempts)
+	if d <= 0 || d > max {
+		return max
+	}
+	return d
+}
+
+// PurgeOutbox удаляет доставленные события старше retention
+func (s *Service) PurgeOutbox(ctx context.Context, retention time.Duration) error {
+	if retention <= 0 {
+		return fmt.Errorf("outbox retention must be positive")
+	}
+
+	deleted, err := s.repo.DeleteDeliveredOutboxEvents(time.Now().Add(-retention))
+	if err != nil {
+		return fmt.Errorf("failed to purge outbox: %w", err)
+	}
+	if deleted > 0 {
+		log.Printf("Purged %d delivered outbox events older than %s", deleted, retention)
+	}
+	return nil
+}
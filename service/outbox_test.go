@@ -0,0 +1,146 @@
+package service
+
+import (
+	"avito/models"
+	"context"
+	"errors"
+	"reflect"
+	"sort"
+	"testing"
+	"time"
+)
+
+// fakeOutbox outbox в памяти: claim выдает, как и ClaimOutboxEvents, только самое раннее
+// недоставленное событие каждого агрегата, если для него наступило время попытки
+type fakeOutbox struct {
+	events      []models.OutboxEvent
+	delivered   map[int64]bool
+	nextAttempt map[int64]time.Time
+	lastError   map[int64]string
+}
+
+func newFakeOutbox(events ...models.OutboxEvent) *fakeOutbox {
+	return &fakeOutbox{
+		events:      events,
+		delivered:   make(map[int64]bool),
+		nextAttempt: make(map[int64]time.Time),
+		lastError:   make(map[int64]string),
+	}
+}
+
+func (o *fakeOutbox) claim(now time.Time) []models.OutboxEvent {
+	heads := make(map[string]bool)
+	var claimed []models.OutboxEvent
+	for _, ev := range o.events {
+		key := ev.AggregateType + "/" + ev.AggregateID
+		if o.delivered[ev.ID] || heads[key] {
+			continue
+		}
+		heads[key] = true
+		if !o.nextAttempt[ev.ID].After(now) {
+			claimed = append(claimed, ev)
+		}
+	}
+	sort.Slice(claimed, func(i, j int) bool { return claimed[i].ID < claimed[j].ID })
+	return claimed
+}
+
+func (o *fakeOutbox) attempt(id int64) {
+	for i := range o.events {
+		if o.events[i].ID == id {
+			o.events[i].Attempts++
+		}
+	}
+}
+
+func (o *fakeOutbox) MarkOutboxDelivered(id int64, deliveredAt time.Time) error {
+	o.attempt(id)
+	o.delivered[id] = true
+	return nil
+}
+
+func (o *fakeOutbox) MarkOutboxFailed(id int64, lastError string, nextAttemptAt time.Time) error {
+	o.attempt(id)
+	o.lastError[id] = lastError
+	o.nextAttempt[id] = nextAttemptAt
+	return nil
+}
+
+// fakeSink запоминает порядок отправки и отклоняет события из failures заданное число раз
+type fakeSink struct {
+	published []int64
+	failures  map[int64]int
+}
+
+func (s *fakeSink) Publish(ctx context.Context, ev models.OutboxEvent) error {
+	s.published = append(s.published, ev.ID)
+	if s.failures[ev.ID] > 0 {
+		s.failures[ev.ID]--
+		return errors.New("broker unavailable")
+	}
+	return nil
+}
+
+func TestDeliverOutboxEventsRetriesInAggregateOrder(t *testing.T) {
+	store := newFakeOutbox(
+		models.OutboxEvent{ID: 1, Type: models.OutboxTeamChanged, AggregateType: models.AuditEntityTeam, AggregateID: "backend"},
+		models.OutboxEvent{ID: 2, Type: models.OutboxUserDeactivated, AggregateType: models.AuditEntityUser, AggregateID: "u1"},
+		models.OutboxEvent{ID: 3, Type: models.OutboxTeamChanged, AggregateType: models.AuditEntityTeam, AggregateID: "backend"},
+		models.OutboxEvent{ID: 4, Type: models.OutboxUserActivated, AggregateType: models.AuditEntityUser, AggregateID: "u1"},
+	)
+	sink := &fakeSink{failures: map[int64]int{1: 1}}
+	ctx := context.Background()
+
+	// Первая пачка: событие 1 не доставлено, событие 2 другого агрегата доставляется несмотря на это
+	before := time.Now()
+	delivered, err := deliverOutboxEvents(ctx, sink, store, store.claim(time.Now()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if delivered != 1 || !store.delivered[2] || store.delivered[1] {
+		t.Fatalf("first batch: delivered %d, state %v", delivered, store.delivered)
+	}
+	if store.lastError[1] != "broker unavailable" {
+		t.Errorf("last error = %q", store.lastError[1])
+	}
+	next := store.nextAttempt[1]
+	if min, max := before.Add(retryBackoff(0, time.Second, outboxMaxBackoff)), time.Now().Add(time.Second); next.Before(min) || next.After(max) {
+		t.Errorf("next attempt at %v, want between %v and %v", next, min, max)
+	}
+
+	// До истечения паузы событие 1 не повторяется, а событие 3 его агрегата ждет
+	if _, err := deliverOutboxEvents(ctx, sink, store, store.claim(time.Now())); err != nil {
+		t.Fatal(err)
+	}
+	if store.delivered[1] || store.delivered[3] || !store.delivered[4] {
+		t.Fatalf("second batch: state %v", store.delivered)
+	}
+
+	// После паузы событие 1 повторяется, и только затем отправляется событие 3
+	for round := 0; round < 2; round++ {
+		if _, err := deliverOutboxEvents(ctx, sink, store, store.claim(time.Now().Add(outboxMaxBackoff))); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if want := []int64{1, 2, 4, 1, 3}; !reflect.DeepEqual(sink.published, want) {
+		t.Errorf("published %v, want %v", sink.published, want)
+	}
+	if store.events[0].Attempts != 2 {
+		t.Errorf("event 1 attempts = %d, want 2", store.events[0].Attempts)
+	}
+	if len(store.claim(time.Now().Add(outboxMaxBackoff))) != 0 {
+		t.Error("outbox is not drained")
+	}
+}
+
+func TestDeliverOutboxEventsStopsAfterLease(t *testing.T) {
+	store := newFakeOutbox(models.OutboxEvent{ID: 1, AggregateType: models.AuditEntityUser, AggregateID: "u1"})
+	sink := &fakeSink{}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	delivered, err := deliverOutboxEvents(ctx, sink, store, store.claim(time.Now()))
+	if err != nil || delivered != 0 || len(sink.published) != 0 {
+		t.Errorf("delivered %d, published %v, err %v; want nothing sent", delivered, sink.published, err)
+	}
+}
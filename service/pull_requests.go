@@ -0,0 +1,112 @@
+package service
+
+import (
+	"avito/models"
+	"fmt"
+	"time"
+)
+
+// GetPR возвращает PR со всеми ревьюверами и метками
+func (s *Service) GetPR(pullRequestID string) (*models.PullRequest, error) {
+	if pullRequestID == "" {
+		return nil, fmt.Errorf("pull request ID cannot be empty")
+	}
+
+	pr, err := s.repo.GetPR(pullRequestID)
+	if err != nil {
+		return nil, fmt.Errorf("NOT_FOUND: %w", err)
+	}
+	return pr, nil
+}
+
+// ListPRs возвращает страницу PR по фильтрам; cursor - значение next_cursor предыдущей страницы
+func (s *Service) ListPRs(filter models.PRFilter, cursor string) (*models.PRListResponse, error) {
+	if filter.Status != "" && !isValidStatus(filter.Status) {
+		return nil, fmt.Errorf("invalid status: %s", filter.Status)
+	}
+	if cursor != "" {
+		keys, err := decodeCursor(cursor, 2)
+		if err != nil {
+			return nil, err
+		}
+		createdAt, err := time.Parse(time.RFC3339Nano, keys[0])
+		if err != nil {
+			return nil, fmt.Errorf("invalid cursor")
+		}
+		filter.AfterCreatedAt = &createdAt
+		filter.AfterID = keys[1]
+	}
+	filter.Labels = normalizeLabels(filter.Labels)
+
+	limit := pageLimit(filter.Limit)
+	filter.Limit = limit + 1
+	prs, err := s.repo.ListPRs(filter)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list PRs: %w", err)
+	}
+
+	resp := &models.PRListResponse{PullRequests: []models.PullRequest{}}
+	for i, pr := range prs {
+		if i == limit {
+			last := prs[i-1]
+			resp.NextCursor = encodeCursor(last.CreatedAt.Format(time.RFC3339Nano), last.PullRequestID)
+			break
+		}
+		resp.PullRequests = append(resp.PullRequests, *pr)
+	}
+	return resp, nil
+}
+
+func isValidStatus(status string) bool {
+	return status == "OPEN" || status == "MERGED" || status == "CLOSED"
+}
+
+// closePR закрывает PR без merge (идемпотентная операция)
+func (s *Service) closePR(pullRequestID, reason string) (*models.PullRequest, error) {
+	pr, err := s.repo.GetPR(pullRequestID)
+	if err != nil {
+		return nil, fmt.Errorf("NOT_FOUND: PR not found")
+	}
+	if pr.Status == "CLOSED" {
+		return pr, nil
+	}
+	if pr.Status == "MERGED" {
+		return nil, fmt.Errorf("PR_MERGED: cannot close merged PR")
+	}
+
+	err = s.inTx(func(tx *Service) error {
+		if _, err := tx.repo.ClosePR(pullRequestID, time.Now()); err != nil {
+			return fmt.Errorf("failed to close PR: %w", err)
+		}
+		return tx.recordEvent(pullRequestID, models.EventClosed, map[string]interface{}{"reason": reason})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return s.repo.GetPR(pullRequestID)
+}
+
+// reopenPR возвращает закрытый PR в работу с прежними ревьюверами (идемпотентная операция)
+func (s *Service) reopenPR(pullRequestID string) (*models.PullRequest, error) {
+	pr, err := s.repo.GetPR(pullRequestID)
+	if err != nil {
+		return nil, fmt.Errorf("NOT_FOUND: PR not found")
+	}
+	if pr.Status == "OPEN" {
+		return pr, nil
+	}
+	if pr.Status == "MERGED" {
+		return nil, fmt.Errorf("PR_MERGED: cannot reopen merged PR")
+	}
+
+	err = s.inTx(func(tx *Service) error {
+		if _, err := tx.repo.ReopenPR(pullRequestID); err != nil {
+			return fmt.Errorf("failed to reopen PR: %w", err)
+		}
+		return tx.recordEvent(pullRequestID, models.EventReopened, nil)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return s.repo.GetPR(pullRequestID)
+}
@@ -1,22 +1,79 @@
 package service
 
 import (
+	"avito/codehost"
 	"avito/models"
+	"avito/notifier"
+	"avito/outbox"
 	"avito/repository"
+	"avito/webhook"
 	"fmt"
+	"strconv"
+	"strings"
 	"time"
 )
 
 type Service struct {
-	repo *repository.Repository
+	repo     *repository.Repository
+	notifier notifier.Notifier
+	outbox   outbox.Sink
+	webhooks *webhook.Client
+	codehost codehost.Client
+	caller   Caller
+	events   notifier.Notifier // канал уведомлений о назначениях и merge; nil - выключены
+}
+
+// ActorSystem исполнитель фоновых задач и вызовов без указанного исполнителя
+const ActorSystem = "system"
+
+// Caller исполнитель операций и запрос, в рамках которого они выполняются.
+// Попадает в историю PR и журнал аудита
+type Caller struct {
+	Actor     string
+	RequestID string
+	Endpoint  string
 }
 
 func NewService(repo *repository.Repository) *Service {
-	return &Service{repo: repo}
+	return &Service{repo: repo, notifier: notifier.NopNotifier{}, outbox: outbox.NopSink{},
+		webhooks: webhook.NewClient(nil), caller: Caller{Actor: ActorSystem}}
+}
+
+// WithCaller возвращает копию сервиса, выполняющую операции от имени caller
+func (s *Service) WithCaller(caller Caller) *Service {
+	if caller.Actor == "" {
+		caller.Actor = ActorSystem
+	}
+	c := *s
+	c.caller = caller
+	return &c
+}
+
+// inTx выполняет fn в транзакции; сервис, переданный в fn, работает через транзакционный репозиторий
+func (s *Service) inTx(fn func(tx *Service) error) error {
+	return s.repo.InTx(func(repo *repository.Repository) error {
+		tx := *s
+		tx.repo = repo
+		return fn(&tx)
+	})
+}
+
+// SetNotifier задает канал доставки уведомлений
+func (s *Service) SetNotifier(n notifier.Notifier) {
+	if n == nil {
+		n = notifier.NopNotifier{}
+	}
+	s.notifier = n
 }
 
-// CreateTeam создает команду с участниками (создает/обновляет пользователей)
-func (s *Service) CreateTeam(req *models.CreateTeamRequest) (*models.Team, error) {
+// SetEventNotifier задает канал уведомлений о назначениях, переназначениях и merge (чат);
+// nil - такие уведомления не ставятся в очередь
+func (s *Service) SetEventNotifier(n notifier.Notifier) {
+	s.events = n
+}
+
+// createTeam создает команду с участниками (создает/обновляет пользователей)
+func (s *Service) createTeam(req *models.CreateTeamRequest) (*models.Team, error) {
 	if req == nil {
 		return nil, fmt.Errorf("request cannot be nil")
 	}
@@ -32,16 +89,24 @@ func (s *Service) CreateTeam(req *models.CreateTeamRequest) (*models.Team, error
 	if exists {
 		return nil, fmt.Errorf("TEAM_EXISTS: team_name already exists")
 	}
+	if err := s.checkParent(req.TeamName, req.ParentTeamName); err != nil {
+		return nil, err
+	}
 
 	// Создаем команду
 	if err := s.repo.CreateTeam(req.TeamName); err != nil {
 		return nil, fmt.Errorf("failed to create team: %w", err)
 	}
+	if req.ParentTeamName != "" {
+		if err := s.repo.SetTeamParent(req.TeamName, req.ParentTeamName); err != nil {
+			return nil, fmt.Errorf("failed to set parent team: %w", err)
+		}
+	}
 
 	// Создаем/обновляем пользователей и добавляем их в команду
 	for _, member := range req.Members {
-		if err := s.repo.CreateOrUpdateUser(member.UserID, member.Username, member.IsActive); err != nil {
-			return nil, fmt.Errorf("failed to create/update user %s: %w", member.UserID, err)
+		if err := s.saveUser(member.UserID, member.Username, member.IsActive, req.TeamName); err != nil {
+			return nil, err
 		}
 
 		if err := s.repo.AddUserToTeam(req.TeamName, member.UserID); err != nil {
@@ -67,17 +132,88 @@ func (s *Service) GetTeam(teamName string) (*models.Team, error) {
 	return team, nil
 }
 
-// SetUserActive устанавливает флаг активности пользователя
-func (s *Service) SetUserActive(userID string, isActive bool) (*models.User, error) {
+// setTeamPolicy изменяет политику назначения ревьюверов в команде
+func (s *Service) setTeamPolicy(req *models.SetTeamPolicyRequest) (*models.Team, error) {
+	if req == nil {
+		return nil, fmt.Errorf("request cannot be nil")
+	}
+	if req.TeamName == "" {
+		return nil, fmt.Errorf("team name cannot be empty")
+	}
+
+	policy, err := s.repo.GetTeamPolicy(req.TeamName)
+	if err != nil {
+		return nil, fmt.Errorf("NOT_FOUND: %w", err)
+	}
+
+	if req.LargePREffortThreshold != nil {
+		if *req.LargePREffortThreshold < 0 {
+			return nil, fmt.Errorf("large PR effort threshold cannot be negative")
+		}
+		policy.LargePREffortThreshold = *req.LargePREffortThreshold
+	}
+	if req.LargePRExtraReviewers != nil {
+		if *req.LargePRExtraReviewers < 0 {
+			return nil, fmt.Errorf("large PR extra reviewers cannot be negative")
+		}
+		policy.LargePRExtraReviewers = *req.LargePRExtraReviewers
+	}
+	if req.AutoCloseAfterDays != nil {
+		if *req.AutoCloseAfterDays < 0 {
+			return nil, fmt.Errorf("auto close after days cannot be negative")
+		}
+		policy.AutoCloseAfterDays = *req.AutoCloseAfterDays
+	}
+
+	if err := s.repo.UpdateTeamPolicy(req.TeamName, policy); err != nil {
+		return nil, fmt.Errorf("failed to update team policy: %w", err)
+	}
+
+	return s.repo.GetTeam(req.TeamName)
+}
+
+// setReviewerProfile изменяет флаги наставничества пользователя
+func (s *Service) setReviewerProfile(req *models.SetReviewerProfileRequest) (*models.User, error) {
+	if req == nil {
+		return nil, fmt.Errorf("request cannot be nil")
+	}
+	if req.UserID == "" {
+		return nil, fmt.Errorf("user ID cannot be empty")
+	}
+
+	user, err := s.repo.GetUser(req.UserID)
+	if err != nil {
+		return nil, fmt.Errorf("NOT_FOUND: user not found")
+	}
+
+	if req.IsLearningReviewer != nil {
+		user.IsLearningReviewer = *req.IsLearningReviewer
+	}
+	if req.IsSenior != nil {
+		user.IsSenior = *req.IsSenior
+	}
+
+	if err := s.repo.UpdateReviewerProfile(req.UserID, user.IsLearningReviewer, user.IsSenior); err != nil {
+		return nil, fmt.Errorf("failed to update reviewer profile: %w", err)
+	}
+
+	return s.repo.GetUserDetails(req.UserID)
+}
+
+// setUserActive устанавливает флаг активности пользователя
+func (s *Service) setUserActive(userID string, isActive bool) (*models.User, error) {
 	if userID == "" {
 		return nil, fmt.Errorf("user ID cannot be empty")
 	}
 
 	// Проверяем существование пользователя
-	_, err := s.repo.GetUser(userID)
+	user, err := s.repo.GetUser(userID)
 	if err != nil {
 		return nil, fmt.Errorf("NOT_FOUND: user not found")
 	}
+	if isActive && user.DepartedAt != nil {
+		return nil, fmt.Errorf("USER_DEPARTED: departed user cannot be activated")
+	}
 
 	// Обновляем активность
 	if err := s.repo.UpdateUserActivity(userID, isActive); err != nil {
@@ -85,11 +221,11 @@ func (s *Service) SetUserActive(userID string, isActive bool) (*models.User, err
 	}
 
 	// Возвращаем обновленного пользователя
-	return s.repo.GetUser(userID)
+	return s.repo.GetUserDetails(userID)
 }
 
-// CreatePR создает новый PR и автоматически назначает ревьюверов
-func (s *Service) CreatePR(req *models.CreatePRRequest) (*models.PullRequest, error) {
+// createPR создает новый PR и автоматически назначает ревьюверов
+func (s *Service) createPR(req *models.CreatePRRequest) (*models.PullRequest, error) {
 	if req == nil {
 		return nil, fmt.Errorf("request cannot be nil")
 	}
@@ -103,6 +239,18 @@ func (s *Service) CreatePR(req *models.CreatePRRequest) (*models.PullRequest, er
 		return nil, fmt.Errorf("author ID cannot be empty")
 	}
 
+	priority := req.Priority
+	if priority == "" {
+		priority = models.PriorityNormal
+	}
+	if !isValidPriority(priority) {
+		return nil, fmt.Errorf("invalid priority: %s (must be low, normal, high or hotfix)", priority)
+	}
+	if req.Additions < 0 || req.Deletions < 0 || req.FilesChanged < 0 {
+		return nil, fmt.Errorf("PR size values cannot be negative")
+	}
+	effort := ReviewEffort(req.Additions, req.Deletions, req.FilesChanged)
+
 	// Проверяем, существует ли PR
 	exists, err := s.repo.PRExists(req.PullRequestID)
 	if err != nil {
@@ -124,38 +272,90 @@ func (s *Service) CreatePR(req *models.CreatePRRequest) (*models.PullRequest, er
 		return nil, fmt.Errorf("NOT_FOUND: author is not a member of any team")
 	}
 
-	// Получаем активных участников команды, исключая автора
-	candidates, err := s.repo.GetActiveTeamMembersExcept(teamName, req.AuthorID)
+	// Получаем активных участников команды, исключая автора.
+	// Если в команде нет обычных ревьюверов, ищем их в родительских командах
+	candidates, _, err := s.findCandidates(teamName, func(members []*models.User) ([]*models.User, error) {
+		result := []*models.User{}
+		hasRegular := false
+		for _, m := range members {
+			if m.UserID == req.AuthorID {
+				continue
+			}
+			result = append(result, m)
+			hasRegular = hasRegular || !m.IsLearningReviewer
+		}
+		if !hasRegular {
+			return nil, nil
+		}
+		return result, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// Крупным PR политика команды может добавлять ревьюверов
+	policy, err := s.repo.GetTeamPolicy(teamName)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get team members: %w", err)
+		return nil, fmt.Errorf("failed to get team policy: %w", err)
+	}
+	reviewerCount := 2
+	if policy.LargePREffortThreshold > 0 && effort >= policy.LargePREffortThreshold {
+		reviewerCount += policy.LargePRExtraReviewers
 	}
 
-	// Выбираем до 2 случайных ревьюверов
-	reviewers := repository.SelectRandomReviewers(candidates, 2)
-	reviewerIDs := make([]string, len(reviewers))
-	for i, r := range reviewers {
-		reviewerIDs[i] = r.UserID
+	reviewers, err := s.selectReviewers(candidates, reviewerCount, priority)
+	if err != nil {
+		return nil, err
 	}
 
 	now := time.Now()
 	pr := &models.PullRequest{
-		PullRequestID:     req.PullRequestID,
-		PullRequestName:   req.PullRequestName,
-		AuthorID:          req.AuthorID,
-		Status:            "OPEN",
-		AssignedReviewers: reviewerIDs,
-		CreatedAt:         &now,
-	}
-
-	if err := s.repo.CreatePR(pr); err != nil {
-		return nil, fmt.Errorf("failed to create PR: %w", err)
+		PullRequestID:   req.PullRequestID,
+		PullRequestName: req.PullRequestName,
+		AuthorID:        req.AuthorID,
+		Status:          "OPEN",
+		Reviewers:       reviewers,
+		Priority:        priority,
+		Labels:          normalizeLabels(req.Labels),
+		Additions:       req.Additions,
+		Deletions:       req.Deletions,
+		FilesChanged:    req.FilesChanged,
+		ReviewEffort:    effort,
+		CreatedAt:       &now,
+	}
+
+	err = s.inTx(func(tx *Service) error {
+		if err := tx.repo.CreatePR(pr); err != nil {
+			return fmt.Errorf("failed to create PR: %w", err)
+		}
+		if err := tx.recordEvent(pr.PullRequestID, models.EventCreated, map[string]interface{}{
+			"pull_request_name": pr.PullRequestName,
+			"author_id":         pr.AuthorID,
+			"priority":          pr.Priority,
+			"labels":            pr.Labels,
+			"review_effort":     pr.ReviewEffort,
+		}); err != nil {
+			return err
+		}
+		for _, reviewer := range pr.Reviewers {
+			if err := tx.recordEvent(pr.PullRequestID, models.EventReviewerAssigned, map[string]interface{}{
+				"reviewer_id": reviewer.UserID,
+				"role":        reviewer.Role,
+			}); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
 	}
 
 	return s.repo.GetPR(req.PullRequestID)
 }
 
-// ReassignReviewer переназначает ревьювера
-func (s *Service) ReassignReviewer(pullRequestID, oldUserID string) (*models.PullRequest, string, error) {
+// reassignReviewer переназначает ревьювера
+func (s *Service) reassignReviewer(pullRequestID, oldUserID string) (*models.PullRequest, string, error) {
 	if pullRequestID == "" {
 		return nil, "", fmt.Errorf("pull request ID cannot be empty")
 	}
@@ -173,16 +373,13 @@ func (s *Service) ReassignReviewer(pullRequestID, oldUserID string) (*models.Pul
 	if pr.Status == "MERGED" {
 		return nil, "", fmt.Errorf("PR_MERGED: cannot reassign on merged PR")
 	}
+	if pr.Status == "CLOSED" {
+		return nil, "", fmt.Errorf("PR_CLOSED: cannot reassign on closed PR")
+	}
 
 	// Проверяем, что старый ревьювер действительно назначен
-	found := false
-	for _, rid := range pr.AssignedReviewers {
-		if rid == oldUserID {
-			found = true
-			break
-		}
-	}
-	if !found {
+	role := reviewerRole(pr, oldUserID)
+	if role == "" {
 		return nil, "", fmt.Errorf("NOT_ASSIGNED: reviewer is not assigned to this PR")
 	}
 
@@ -192,29 +389,17 @@ func (s *Service) ReassignReviewer(pullRequestID, oldUserID string) (*models.Pul
 		return nil, "", fmt.Errorf("NOT_FOUND: old reviewer is not a member of any team")
 	}
 
-	// Получаем активных участников команды
-	candidates, err := s.repo.GetActiveTeamMembers(teamName)
+	// Ищем среди активных участников команды, исключая уже назначенных ревьюверов и автора
+	// и учитывая роль заменяемого. Если в команде никого нет, поднимаемся к родительским командам
+	availableCandidates, _, err := s.findCandidates(teamName, func(members []*models.User) ([]*models.User, error) {
+		return s.replacementCandidates(pr, oldUserID, role, members)
+	})
 	if err != nil {
-		return nil, "", fmt.Errorf("failed to get team members: %w", err)
-	}
-
-	// Исключаем уже назначенных ревьюверов и автора
-	availableCandidates := []*models.User{}
-	for _, candidate := range candidates {
-		isAssigned := false
-		for _, rid := range pr.AssignedReviewers {
-			if candidate.UserID == rid {
-				isAssigned = true
-				break
-			}
-		}
-		if !isAssigned && candidate.UserID != pr.AuthorID && candidate.UserID != oldUserID {
-			availableCandidates = append(availableCandidates, candidate)
-		}
+		return nil, "", err
 	}
 
 	if len(availableCandidates) == 0 {
-		return nil, "", fmt.Errorf("NO_CANDIDATE: no active replacement candidate in team")
+		return nil, "", fmt.Errorf("NO_CANDIDATE: no active replacement candidate in team or its parent teams")
 	}
 
 	// Выбираем случайного нового ревьювера
@@ -226,8 +411,8 @@ func (s *Service) ReassignReviewer(pullRequestID, oldUserID string) (*models.Pul
 	newReviewerID := newReviewers[0].UserID
 
 	// Заменяем ревьювера
-	if err := s.repo.ReplaceReviewer(pullRequestID, oldUserID, newReviewerID); err != nil {
-		return nil, "", fmt.Errorf("failed to replace reviewer: %w", err)
+	if err := s.replaceReviewer(pullRequestID, oldUserID, newReviewerID, role); err != nil {
+		return nil, "", err
 	}
 
 	// Возвращаем обновленный PR
@@ -239,8 +424,8 @@ func (s *Service) ReassignReviewer(pullRequestID, oldUserID string) (*models.Pul
 	return updatedPR, newReviewerID, nil
 }
 
-// MergePR выполняет merge PR (идемпотентная операция)
-func (s *Service) MergePR(pullRequestID string) (*models.PullRequest, error) {
+// mergePR выполняет merge PR (идемпотентная операция)
+func (s *Service) mergePR(pullRequestID string) (*models.PullRequest, error) {
 	if pullRequestID == "" {
 		return nil, fmt.Errorf("pull request ID cannot be empty")
 	}
@@ -254,42 +439,201 @@ func (s *Service) MergePR(pullRequestID string) (*models.PullRequest, error) {
 	if pr.Status == "MERGED" {
 		return pr, nil
 	}
+	if pr.Status == "CLOSED" {
+		return nil, fmt.Errorf("PR_CLOSED: cannot merge closed PR")
+	}
 
 	// Выполняем merge
 	// Валидация статуса уже выполнена выше (проверка на "MERGED")
 	now := time.Now()
-	if err := s.repo.UpdatePRStatus(pullRequestID, "MERGED", &now); err != nil {
-		return nil, fmt.Errorf("failed to merge PR: %w", err)
+	err = s.inTx(func(tx *Service) error {
+		if err := tx.repo.UpdatePRStatus(pullRequestID, "MERGED", &now); err != nil {
+			return fmt.Errorf("failed to merge PR: %w", err)
+		}
+		return tx.recordEvent(pullRequestID, models.EventMerged, map[string]interface{}{"merged_at": now})
+	})
+	if err != nil {
+		return nil, err
 	}
 
 	return s.repo.GetPR(pullRequestID)
 }
 
-// GetReview возвращает список PR, назначенных ревьюверу
-func (s *Service) GetReview(userID string) (*models.GetReviewResponse, error) {
-	if userID == "" {
+// GetReview возвращает очередь ревью пользователя. По умолчанию - только OPEN PR
+// с сортировкой по приоритету; status=ALL снимает фильтр по статусу
+func (s *Service) GetReview(filter models.ReviewFilter, cursor string) (*models.GetReviewResponse, error) {
+	if filter.ReviewerID == "" {
 		return nil, fmt.Errorf("user ID cannot be empty")
 	}
 
 	// Проверяем существование пользователя
-	_, err := s.repo.GetUser(userID)
+	_, err := s.repo.GetUser(filter.ReviewerID)
 	if err != nil {
 		return nil, fmt.Errorf("NOT_FOUND: user not found")
 	}
 
-	prs, err := s.repo.GetPRsByReviewer(userID)
+	switch filter.Status {
+	case "":
+		filter.Status = "OPEN"
+	case "ALL":
+		filter.Status = ""
+	default:
+		if !isValidStatus(filter.Status) {
+			return nil, fmt.Errorf("invalid status: %s", filter.Status)
+		}
+	}
+
+	if filter.Sort == "" {
+		filter.Sort = models.ReviewSortPriority
+	}
+	keyCount := 2
+	switch filter.Sort {
+	case models.ReviewSortPriority:
+		keyCount = 3
+	case models.ReviewSortAge:
+	default:
+		return nil, fmt.Errorf("invalid sort: %s (must be priority or age)", filter.Sort)
+	}
+
+	if cursor != "" {
+		keys, err := decodeCursor(cursor, keyCount)
+		if err != nil {
+			return nil, err
+		}
+		if keyCount == 3 {
+			if filter.AfterPriority, err = strconv.Atoi(keys[0]); err != nil {
+				return nil, fmt.Errorf("invalid cursor")
+			}
+			keys = keys[1:]
+		}
+		createdAt, err := time.Parse(time.RFC3339Nano, keys[0])
+		if err != nil {
+			return nil, fmt.Errorf("invalid cursor")
+		}
+		filter.AfterCreatedAt = &createdAt
+		filter.AfterID = keys[1]
+	}
+	filter.Labels = normalizeLabels(filter.Labels)
+
+	limit := pageLimit(filter.Limit)
+	filter.Limit = limit + 1
+	items, err := s.repo.GetPRsByReviewer(filter)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get PRs: %w", err)
 	}
 
-	// Преобразуем []*models.PullRequestShort в []models.PullRequestShort
-	pullRequests := make([]models.PullRequestShort, len(prs))
-	for i, pr := range prs {
-		pullRequests[i] = *pr
+	resp := &models.GetReviewResponse{
+		UserID:       filter.ReviewerID,
+		PullRequests: []models.ReviewItem{},
+	}
+	for i, item := range items {
+		if i == limit {
+			last := items[i-1]
+			keys := []string{last.CreatedAt.Format(time.RFC3339Nano), last.PullRequestID}
+			if filter.Sort == models.ReviewSortPriority {
+				keys = append([]string{strconv.Itoa(priorityOrder(last.Priority))}, keys...)
+			}
+			resp.NextCursor = encodeCursor(keys...)
+			break
+		}
+		resp.PullRequests = append(resp.PullRequests, *item)
+	}
+	return resp, nil
+}
+
+// submitReview сохраняет вердикт ревьювера по PR
+func (s *Service) submitReview(req *models.SubmitReviewRequest) (*models.PullRequest, error) {
+	if req == nil {
+		return nil, fmt.Errorf("request cannot be nil")
+	}
+	if req.PullRequestID == "" {
+		return nil, fmt.Errorf("pull request ID cannot be empty")
+	}
+	if req.ReviewerID == "" {
+		return nil, fmt.Errorf("reviewer ID cannot be empty")
+	}
+	switch req.Verdict {
+	case models.VerdictApproved, models.VerdictChangesRequested, models.VerdictCommented:
+	default:
+		return nil, fmt.Errorf("invalid verdict: %s (must be APPROVED, CHANGES_REQUESTED or COMMENTED)", req.Verdict)
+	}
+
+	pr, err := s.repo.GetPR(req.PullRequestID)
+	if err != nil {
+		return nil, fmt.Errorf("NOT_FOUND: PR not found")
+	}
+	if pr.Status == "MERGED" {
+		return nil, fmt.Errorf("PR_MERGED: cannot review merged PR")
+	}
+	if pr.Status == "CLOSED" {
+		return nil, fmt.Errorf("PR_CLOSED: cannot review closed PR")
+	}
+	if !hasReviewer(pr, req.ReviewerID) {
+		return nil, fmt.Errorf("NOT_ASSIGNED: reviewer is not assigned to this PR")
+	}
+
+	err = s.inTx(func(tx *Service) error {
+		if err := tx.repo.SetReviewVerdict(req.PullRequestID, req.ReviewerID, req.Verdict, time.Now()); err != nil {
+			return fmt.Errorf("failed to save review verdict: %w", err)
+		}
+		return tx.recordEvent(req.PullRequestID, models.EventReviewSubmitted, map[string]interface{}{
+			"reviewer_id": req.ReviewerID,
+			"verdict":     req.Verdict,
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return s.repo.GetPR(req.PullRequestID)
+}
+
+// priorityOrder порядок приоритета в очереди ревью (совпадает с сортировкой в репозитории)
+func priorityOrder(priority string) int {
+	switch priority {
+	case models.PriorityHotfix:
+		return 0
+	case models.PriorityHigh:
+		return 1
+	case models.PriorityNormal:
+		return 2
+	}
+	return 3
+}
+
+func isValidPriority(priority string) bool {
+	switch priority {
+	case models.PriorityLow, models.PriorityNormal, models.PriorityHigh, models.PriorityHotfix:
+		return true
+	}
+	return false
+}
+
+// normalizeLabels убирает пустые и повторяющиеся метки
+func normalizeLabels(labels []string) []string {
+	result := []string{}
+	seen := make(map[string]bool, len(labels))
+	for _, label := range labels {
+		label = strings.TrimSpace(label)
+		if label == "" || seen[label] {
+			continue
+		}
+		seen[label] = true
+		result = append(result, label)
 	}
+	return result
+}
+
+func userIDs(users []*models.User) []string {
+	ids := make([]string, len(users))
+	for i, u := range users {
+		ids[i] = u.UserID
+	}
+	return ids
+}
 
-	return &models.GetReviewResponse{
-		UserID:       userID,
-		PullRequests: pullRequests,
-	}, nil
+// ReviewEffort оценивает трудоемкость ревью PR в условных единицах:
+// 1 за сам PR, плюс 1 за каждые 100 измененных строк и каждые 10 файлов
+func ReviewEffort(additions, deletions, filesChanged int) int {
+	return 1 + (additions+deletions)/100 + filesChanged/10
 }
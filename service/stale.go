@@ -0,0 +1,80 @@
+package service
+
+import (
+	"avito/models"
+	"avito/notifier"
+	"context"
+	"fmt"
+	"log"
+	"time"
+)
+
+// GetStalePRs возвращает OPEN PR старше olderThan вместе с ревьюверами и временем последней активности
+func (s *Service) GetStalePRs(olderThan time.Duration, teamName string) (*models.StalePRsResponse, error) {
+	if olderThan <= 0 {
+		return nil, fmt.Errorf("older_than must be positive")
+	}
+	if teamName != "" {
+		if err := s.requireTeam(teamName); err != nil {
+			return nil, err
+		}
+	}
+
+	prs, err := s.repo.GetStalePRs(time.Now().Add(-olderThan), teamName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get stale PRs: %w", err)
+	}
+
+	resp := &models.StalePRsResponse{PullRequests: make([]models.StalePR, len(prs))}
+	for i, pr := range prs {
+		resp.PullRequests[i] = *pr
+	}
+	return resp, nil
+}
+
+// CloseInactivePRs закрывает OPEN PR, неактивные дольше auto_close_after_days команды автора,
+// и уведомляет авторов
+func (s *Service) CloseInactivePRs(ctx context.Context) error {
+	now := time.Now()
+	prs, err := s.repo.GetPRsToAutoClose(now)
+	if err != nil {
+		return fmt.Errorf("failed to get inactive PRs: %w", err)
+	}
+
+	for _, pr := range prs {
+		var closed bool
+		err := s.inTx(func(tx *Service) error {
+			var err error
+			closed, err = tx.repo.ClosePR(pr.PullRequestID, now)
+			if err != nil {
+				return fmt.Errorf("failed to close PR %s: %w", pr.PullRequestID, err)
+			}
+			if !closed {
+				return nil
+			}
+			return tx.recordEvent(pr.PullRequestID, models.EventClosed, map[string]interface{}{"reason": "inactivity"})
+		})
+		if err != nil {
+			return err
+		}
+		// PR мог быть смержен между выборкой и закрытием
+		if !closed {
+			continue
+		}
+
+		pr.Status = "CLOSED"
+		msg := notifier.Message{
+			Kind:         notifier.KindAutoClosed,
+			UserID:       pr.AuthorID,
+			Subject:      fmt.Sprintf("Pull request %s was closed due to inactivity", pr.PullRequestID),
+			Text:         fmt.Sprintf("%s (%s) had no review activity for longer than the team allows", pr.PullRequestName, pr.PullRequestID),
+			PullRequests: []models.PullRequestShort{pr},
+			CreatedAt:    now,
+		}
+		if err := s.notifier.Notify(ctx, msg); err != nil {
+			log.Printf("Error notifying %s about closed PR %s: %v", pr.AuthorID, pr.PullRequestID, err)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,78 @@
+package service
+
+import (
+	"avito/models"
+	"fmt"
+)
+
+// GetStats возвращает статистику назначений по пользователям, PR и командам
+func (s *Service) GetStats(filter models.StatsFilter) (*models.StatsResponse, error) {
+	if err := s.validateStatsFilter(filter); err != nil {
+		return nil, err
+	}
+
+	users, err := s.repo.GetUserAssignmentStats(filter)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user stats: %w", err)
+	}
+	prs, err := s.repo.GetPRAssignmentStats(filter)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get PR stats: %w", err)
+	}
+	teams, err := s.repo.GetTeamPRStats(filter)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get team stats: %w", err)
+	}
+
+	return &models.StatsResponse{Users: users, PullRequests: prs, Teams: teams}, nil
+}
+
+// GetCycleTime возвращает перцентили времени до merge (по командам или авторам),
+// времени до первого вердикта по ревьюверам и, если задан bucket, временной ряд
+func (s *Service) GetCycleTime(filter models.CycleTimeFilter) (*models.CycleTimeResponse, error) {
+	if err := s.validateStatsFilter(filter.StatsFilter); err != nil {
+		return nil, err
+	}
+
+	switch filter.GroupBy {
+	case "":
+		filter.GroupBy = "team"
+	case "team", "author":
+	default:
+		return nil, fmt.Errorf("invalid group_by: %s (must be team or author)", filter.GroupBy)
+	}
+	switch filter.Bucket {
+	case "", "day", "week", "month":
+	default:
+		return nil, fmt.Errorf("invalid bucket: %s (must be day, week or month)", filter.Bucket)
+	}
+
+	resp := &models.CycleTimeResponse{GroupBy: filter.GroupBy}
+	var err error
+	resp.TimeToMerge, err = s.repo.GetTimeToMerge(filter.StatsFilter, filter.GroupBy == "team")
+	if err != nil {
+		return nil, fmt.Errorf("failed to get time to merge: %w", err)
+	}
+	resp.TimeToFirstReview, err = s.repo.GetTimeToFirstReview(filter.StatsFilter)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get time to first review: %w", err)
+	}
+	if filter.Bucket != "" {
+		resp.Series, err = s.repo.GetTimeToMergeSeries(filter.StatsFilter, filter.Bucket)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get time series: %w", err)
+		}
+	}
+	return resp, nil
+}
+
+// validateStatsFilter проверяет период и существование команды
+func (s *Service) validateStatsFilter(filter models.StatsFilter) error {
+	if filter.From != nil && filter.To != nil && !filter.From.Before(*filter.To) {
+		return fmt.Errorf("from must be before to")
+	}
+	if filter.TeamName != "" {
+		return s.requireTeam(filter.TeamName)
+	}
+	return nil
+}
@@ -0,0 +1,219 @@
+package service
+
+import (
+	"avito/models"
+	"fmt"
+)
+
+// setTeamParent переносит команду под другую родительскую команду
+func (s *Service) setTeamParent(req *models.SetTeamParentRequest) (*models.Team, error) {
+	if req == nil {
+		return nil, fmt.Errorf("request cannot be nil")
+	}
+	if req.TeamName == "" {
+		return nil, fmt.Errorf("team name cannot be empty")
+	}
+
+	exists, err := s.repo.TeamExists(req.TeamName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check team existence: %w", err)
+	}
+	if !exists {
+		return nil, fmt.Errorf("NOT_FOUND: team not found")
+	}
+
+	if err := s.checkParent(req.TeamName, req.ParentTeamName); err != nil {
+		return nil, err
+	}
+
+	if err := s.repo.SetTeamParent(req.TeamName, req.ParentTeamName); err != nil {
+		return nil, fmt.Errorf("failed to set parent team: %w", err)
+	}
+
+	return s.repo.GetTeam(req.TeamName)
+}
+
+// checkParent проверяет, что parentTeamName существует и не является потомком teamName
+func (s *Service) checkParent(teamName, parentTeamName string) error {
+	if parentTeamName == "" {
+		return nil
+	}
+
+	current := parentTeamName
+	visited := map[string]bool{}
+	for current != "" {
+		if current == teamName {
+			return fmt.Errorf("TEAM_CYCLE: team cannot be a descendant of itself")
+		}
+		if visited[current] {
+			return fmt.Errorf("TEAM_CYCLE: team hierarchy already contains a cycle")
+		}
+		visited[current] = true
+
+		parent, err := s.repo.GetTeamParent(current)
+		if err != nil {
+			return fmt.Errorf("NOT_FOUND: parent team %s not found", current)
+		}
+		current = parent
+	}
+	return nil
+}
+
+// GetTeamTree возвращает дерево команд. Если rootTeam задана - только ее поддерево
+func (s *Service) GetTeamTree(rootTeam string) ([]*models.TeamNode, error) {
+	teams, err := s.repo.ListTeams()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list teams: %w", err)
+	}
+
+	nodes := make(map[string]*models.TeamNode, len(teams))
+	for _, t := range teams {
+		nodes[t.TeamName] = &models.TeamNode{
+			TeamName:     t.TeamName,
+			MembersCount: t.MembersCount,
+			Children:     []*models.TeamNode{},
+		}
+	}
+
+	roots := []*models.TeamNode{}
+	for _, t := range teams {
+		node := nodes[t.TeamName]
+		if parent, ok := nodes[t.ParentTeamName]; ok {
+			parent.Children = append(parent.Children, node)
+		} else {
+			roots = append(roots, node)
+		}
+	}
+
+	if rootTeam == "" {
+		return roots, nil
+	}
+	node, ok := nodes[rootTeam]
+	if !ok {
+		return nil, fmt.Errorf("NOT_FOUND: team not found")
+	}
+	return []*models.TeamNode{node}, nil
+}
+
+// findCandidates ищет кандидатов в ревьюверы в команде teamName, а если pick не оставил
+// ни одного - поднимается по родительским командам. Возвращает кандидатов и команду, где они найдены
+func (s *Service) findCandidates(teamName string, pick func([]*models.User) ([]*models.User, error)) ([]*models.User, string, error) {
+	visited := map[string]bool{}
+	for current := teamName; current != "" && !visited[current]; {
+		visited[current] = true
+
+		members, err := s.repo.GetActiveTeamMembers(current)
+		if err != nil {
+			return nil, "", fmt.Errorf("failed to get team members: %w", err)
+		}
+		candidates, err := pick(members)
+		if err != nil {
+			return nil, "", err
+		}
+		if len(candidates) > 0 {
+			return candidates, current, nil
+		}
+
+		current, err = s.repo.GetTeamParent(current)
+		if err != nil {
+			return nil, "", fmt.Errorf("failed to get parent team: %w", err)
+		}
+	}
+	return nil, teamName, nil
+}
+
+// renameTeam переименовывает команду, сохраняя участников, политику и иерархию
+func (s *Service) renameTeam(req *models.RenameTeamRequest) (*models.Team, error) {
+	if req == nil {
+		return nil, fmt.Errorf("request cannot be nil")
+	}
+	if req.TeamName == "" || req.NewTeamName == "" {
+		return nil, fmt.Errorf("team_name and new_team_name cannot be empty")
+	}
+	if req.TeamName == req.NewTeamName {
+		return s.GetTeam(req.TeamName)
+	}
+
+	err := s.inTx(func(tx *Service) error {
+		if err := tx.requireTeam(req.TeamName); err != nil {
+			return err
+		}
+
+		exists, err := tx.repo.TeamExists(req.NewTeamName)
+		if err != nil {
+			return fmt.Errorf("failed to check team existence: %w", err)
+		}
+		if exists {
+			return fmt.Errorf("TEAM_EXISTS: team_name already exists")
+		}
+
+		if err := tx.repo.RenameTeam(req.TeamName, req.NewTeamName); err != nil {
+			return fmt.Errorf("failed to rename team: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return s.repo.GetTeam(req.NewTeamName)
+}
+
+// deleteTeam удаляет команду. Все открытые ревью ее участников (на PR любых команд)
+// переназначаются на участников reassign_to в той же транзакции; без reassign_to удаление отклоняется
+func (s *Service) deleteTeam(req *models.DeleteTeamRequest) (*models.DeleteTeamResponse, error) {
+	if req == nil {
+		return nil, fmt.Errorf("request cannot be nil")
+	}
+	if req.TeamName == "" {
+		return nil, fmt.Errorf("team name cannot be empty")
+	}
+	if req.ReassignTo == req.TeamName {
+		return nil, fmt.Errorf("reassign_to must differ from team_name")
+	}
+
+	reassigned := []models.ReviewReassignment{}
+	err := s.inTx(func(tx *Service) error {
+		team, err := tx.repo.GetTeam(req.TeamName)
+		if err != nil {
+			return fmt.Errorf("NOT_FOUND: %w", err)
+		}
+		if req.ReassignTo != "" {
+			if err := tx.requireTeam(req.ReassignTo); err != nil {
+				return err
+			}
+		}
+
+		for _, member := range team.Members {
+			prIDs, err := tx.repo.GetOpenReviews(member.UserID)
+			if err != nil {
+				return fmt.Errorf("failed to get open reviews: %w", err)
+			}
+			if len(prIDs) > 0 && req.ReassignTo == "" {
+				return fmt.Errorf("TEAM_HAS_OPEN_REVIEWS: members have open reviews, specify reassign_to")
+			}
+
+			for _, prID := range prIDs {
+				newUserID, err := tx.reassignWithinTeam(prID, member.UserID, req.ReassignTo)
+				if err != nil {
+					return err
+				}
+				reassigned = append(reassigned, models.ReviewReassignment{
+					PullRequestID: prID,
+					OldUserID:     member.UserID,
+					NewUserID:     newUserID,
+				})
+			}
+		}
+
+		if err := tx.repo.DeleteTeam(req.TeamName); err != nil {
+			return fmt.Errorf("failed to delete team: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.DeleteTeamResponse{TeamName: req.TeamName, Reassigned: reassigned}, nil
+}
@@ -0,0 +1,174 @@
+package service
+
+import (
+	"avito/models"
+	"avito/repository"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// GetUser возвращает пользователя со всеми командами, навыками и нагрузкой
+func (s *Service) GetUser(userID string) (*models.User, error) {
+	if userID == "" {
+		return nil, fmt.Errorf("user ID cannot be empty")
+	}
+
+	user, err := s.repo.GetUserDetails(userID)
+	if err != nil {
+		return nil, fmt.Errorf("NOT_FOUND: %w", err)
+	}
+	return user, nil
+}
+
+// ListUsers возвращает страницу пользователей по фильтрам; cursor - значение next_cursor предыдущей страницы
+func (s *Service) ListUsers(filter models.UserFilter, cursor string) (*models.UserListResponse, error) {
+	if cursor != "" {
+		keys, err := decodeCursor(cursor, 1)
+		if err != nil {
+			return nil, err
+		}
+		filter.AfterUserID = keys[0]
+	}
+	filter.Limit = pageLimit(filter.Limit)
+	filter.UsernamePrefix = strings.TrimSpace(filter.UsernamePrefix)
+
+	// Запрашиваем на один элемент больше, чтобы понять, есть ли следующая страница
+	limit := filter.Limit
+	filter.Limit++
+	users, err := s.repo.ListUsers(filter)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list users: %w", err)
+	}
+
+	resp := &models.UserListResponse{Users: []models.User{}}
+	for i, u := range users {
+		if i == limit {
+			resp.NextCursor = encodeCursor(users[i-1].UserID)
+			break
+		}
+		resp.Users = append(resp.Users, *u)
+	}
+	return resp, nil
+}
+
+// setUserSkills заменяет список навыков пользователя
+func (s *Service) setUserSkills(req *models.SetUserSkillsRequest) (*models.User, error) {
+	if req == nil {
+		return nil, fmt.Errorf("request cannot be nil")
+	}
+	if req.UserID == "" {
+		return nil, fmt.Errorf("user ID cannot be empty")
+	}
+
+	err := s.inTx(func(tx *Service) error {
+		if _, err := tx.repo.GetUser(req.UserID); err != nil {
+			return fmt.Errorf("NOT_FOUND: user not found")
+		}
+		if err := tx.repo.SetUserSkills(req.UserID, normalizeLabels(req.Skills)); err != nil {
+			return fmt.Errorf("failed to set user skills: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return s.repo.GetUserDetails(req.UserID)
+}
+
+// offboardUser увольняет пользователя: переназначает его открытые ревью, убирает из команд
+// и помечает уволенным. История ревью сохраняется, пользователь больше не назначается ревьювером
+func (s *Service) offboardUser(req *models.OffboardUserRequest) (*models.OffboardUserResponse, error) {
+	if req == nil {
+		return nil, fmt.Errorf("request cannot be nil")
+	}
+	if req.UserID == "" {
+		return nil, fmt.Errorf("user ID cannot be empty")
+	}
+
+	resp := &models.OffboardUserResponse{
+		Reassigned: []models.ReviewReassignment{},
+		Removed:    []string{},
+	}
+	err := s.inTx(func(tx *Service) error {
+		user, err := tx.repo.GetUser(req.UserID)
+		if err != nil {
+			return fmt.Errorf("NOT_FOUND: user not found")
+		}
+		if user.DepartedAt != nil {
+			// Повторное увольнение ничего не меняет
+			return nil
+		}
+
+		prIDs, err := tx.repo.GetOpenReviews(req.UserID)
+		if err != nil {
+			return fmt.Errorf("failed to get open reviews: %w", err)
+		}
+		for _, prID := range prIDs {
+			newUserID, err := tx.replaceDepartedReviewer(prID, user)
+			if err != nil {
+				return err
+			}
+			if newUserID == "" {
+				resp.Removed = append(resp.Removed, prID)
+				continue
+			}
+			resp.Reassigned = append(resp.Reassigned, models.ReviewReassignment{
+				PullRequestID: prID,
+				OldUserID:     req.UserID,
+				NewUserID:     newUserID,
+			})
+		}
+
+		if err := tx.repo.RemoveUserFromAllTeams(req.UserID); err != nil {
+			return fmt.Errorf("failed to remove user from teams: %w", err)
+		}
+		if err := tx.repo.MarkUserDeparted(req.UserID, time.Now()); err != nil {
+			return fmt.Errorf("failed to mark user departed: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	user, err := s.repo.GetUserDetails(req.UserID)
+	if err != nil {
+		return nil, err
+	}
+	resp.User = *user
+	return resp, nil
+}
+
+// replaceDepartedReviewer заменяет уволенного ревьювера кандидатом из его команды (или родительских).
+// Если кандидатов нет, ревьювер просто снимается с PR и возвращается пустая строка
+func (s *Service) replaceDepartedReviewer(pullRequestID string, user *models.User) (string, error) {
+	pr, err := s.repo.GetPR(pullRequestID)
+	if err != nil {
+		return "", fmt.Errorf("NOT_FOUND: PR not found")
+	}
+
+	var candidates []*models.User
+	if user.TeamName != "" {
+		candidates, _, err = s.findCandidates(user.TeamName, func(members []*models.User) ([]*models.User, error) {
+			return s.replacementCandidates(pr, user.UserID, reviewerRole(pr, user.UserID), members)
+		})
+		if err != nil {
+			return "", err
+		}
+	}
+
+	if len(candidates) == 0 {
+		if err := s.removeReviewer(pullRequestID, user.UserID, reviewerRole(pr, user.UserID)); err != nil {
+			return "", err
+		}
+		return "", nil
+	}
+
+	newUserID := repository.SelectRandomReviewers(candidates, 1)[0].UserID
+	if err := s.replaceReviewer(pullRequestID, user.UserID, newUserID, reviewerRole(pr, user.UserID)); err != nil {
+		return "", err
+	}
+	return newUserID, nil
+}
@@ -0,0 +1,223 @@
+package service
+
+import (
+	"avito/models"
+	"avito/webhook"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+const (
+	// webhookBatchSize сколько доставок захватывается за раз
+	webhookBatchSize = 50
+	// webhookClaimTimeout на сколько захватываются доставки; не отправленные за это время выдаются снова
+	webhookClaimTimeout = 5 * time.Minute
+	// webhookMaxAttempts после стольких неудачных попыток доставка получает статус failed
+	webhookMaxAttempts = 8
+	// webhookBaseBackoff и webhookMaxBackoff пауза между попытками: 10s, 20s, 40s... не больше часа
+	webhookBaseBackoff = 10 * time.Second
+	webhookMaxBackoff  = time.Hour
+)
+
+// webhookEventFilters допустимые значения фильтра подписки: типы событий outbox и шаблоны по префиксу
+var webhookEventFilters = map[string]bool{
+	models.OutboxPRCreated:        true,
+	models.OutboxReviewerAssigned: true,
+	models.OutboxReviewerReplaced: true,
+	models.OutboxReviewerRemoved:  true,
+	models.OutboxReviewSubmitted:  true,
+	models.OutboxPRMerged:         true,
+	models.OutboxPRClosed:         true,
+	models.OutboxPRReopened:       true,
+	models.OutboxUserActivated:    true,
+	models.OutboxUserDeactivated:  true,
+	models.OutboxTeamChanged:      true,
+	"pr.*":                        true,
+	"user.*":                      true,
+	"team.*":                      true,
+}
+
+// CreateWebhook создает подписку на события
+func (s *Service) CreateWebhook(req *models.CreateWebhookRequest) (*models.Webhook, error) {
+	if req == nil {
+		return nil, fmt.Errorf("request cannot be nil")
+	}
+	u, err := url.Parse(req.URL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return nil, fmt.Errorf("url must be an absolute http(s) URL")
+	}
+	if req.Secret == "" {
+		return nil, fmt.Errorf("secret cannot be empty")
+	}
+
+	events := []string{}
+	seen := make(map[string]bool)
+	for _, e := range req.Events {
+		if !webhookEventFilters[e] {
+			return nil, fmt.Errorf("unknown event type: %s", e)
+		}
+		if !seen[e] {
+			seen[e] = true
+			events = append(events, e)
+		}
+	}
+
+	wh := &models.Webhook{URL: req.URL, Secret: req.Secret, Events: events}
+	if err := s.repo.CreateWebhook(wh); err != nil {
+		return nil, fmt.Errorf("failed to create webhook: %w", err)
+	}
+	return wh, nil
+}
+
+// ListWebhooks возвращает все подписки
+func (s *Service) ListWebhooks() ([]models.Webhook, error) {
+	webhooks, err := s.repo.ListWebhooks()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list webhooks: %w", err)
+	}
+	return webhooks, nil
+}
+
+// DeleteWebhook удаляет подписку
+func (s *Service) DeleteWebhook(webhookID int64) error {
+	deleted, err := s.repo.DeleteWebhook(webhookID)
+	if err != nil {
+		return fmt.Errorf("failed to delete webhook: %w", err)
+	}
+	if !deleted {
+		return fmt.Errorf("NOT_FOUND: webhook not found")
+	}
+	return nil
+}
+
+// ListWebhookDeliveries возвращает страницу журнала доставок от новых к старым
+func (s *Service) ListWebhookDeliveries(filter models.WebhookDeliveryFilter, cursor string) (*models.WebhookDeliveryListResponse, error) {
+	if filter.Status != "" && filter.Status != models.DeliveryPending &&
+		filter.Status != models.DeliveryDelivered && filter.Status != models.DeliveryFailed {
+		return nil, fmt.Errorf("invalid status: %s", filter.Status)
+	}
+	if cursor != "" {
+		keys, err := decodeCursor(cursor, 1)
+		if err != nil {
+			return nil, err
+		}
+		beforeID, err := strconv.ParseInt(keys[0], 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid cursor")
+		}
+		filter.BeforeID = beforeID
+	}
+
+	limit := pageLimit(filter.Limit)
+	filter.Limit = limit + 1
+	deliveries, err := s.repo.ListWebhookDeliveries(filter)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list webhook deliveries: %w", err)
+	}
+
+	resp := &models.WebhookDeliveryListResponse{Deliveries: []models.WebhookDelivery{}}
+	for i, d := range deliveries {
+		if i == limit {
+			resp.NextCursor = encodeCursor(strconv.FormatInt(deliveries[i-1].ID, 10))
+			break
+		}
+		resp.Deliveries = append(resp.Deliveries, *d)
+	}
+	return resp, nil
+}
+
+// RedeliverWebhook ставит событие доставки deliveryID в очередь повторно; исходная запись журнала не меняется
+func (s *Service) RedeliverWebhook(deliveryID int64) (*models.WebhookDelivery, error) {
+	d, err := s.repo.RequeueWebhookDelivery(deliveryID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("NOT_FOUND: delivery not found")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to requeue webhook delivery: %w", err)
+	}
+	return d, nil
+}
+
+// DeliverWebhooks отправляет ожидающие доставки, пока очередь не опустеет
+func (s *Service) DeliverWebhooks(ctx context.Context) error {
+	for ctx.Err() == nil {
+		n, err := s.deliverWebhookBatch(ctx)
+		if err != nil {
+			return err
+		}
+		if n < webhookBatchSize {
+			return nil
+		}
+	}
+	return nil
+}
+
+// deliverWebhookBatch захватывает пачку доставок, отправляет их вне транзакции и сохраняет
+// результат каждой; возвращает размер пачки. Захват не дает нескольким экземплярам сервиса
+// отправить одно и то же одновременно; неотправленные к его истечению доставки выдаются снова
+func (s *Service) deliverWebhookBatch(ctx context.Context) (int, error) {
+	leaseUntil := time.Now().Add(webhookClaimTimeout)
+	deliveries, webhooks, err := s.repo.ClaimWebhookDeliveries(time.Now(), leaseUntil, webhookBatchSize)
+	if err != nil {
+		return 0, fmt.Errorf("failed to claim webhook deliveries: %w", err)
+	}
+
+	ctx, cancel := context.WithDeadline(ctx, leaseUntil)
+	defer cancel()
+
+	for i, d := range deliveries {
+		if ctx.Err() != nil {
+			break
+		}
+		code, sendErr := s.webhooks.Send(ctx, webhook.Request{
+			URL:        webhooks[i].URL,
+			Secret:     webhooks[i].Secret,
+			DeliveryID: d.ID,
+			EventType:  d.EventType,
+			Body:       d.Payload,
+		})
+
+		recordDeliveryAttempt(d, code, sendErr, time.Now())
+		switch d.Status {
+		case models.DeliveryFailed:
+			log.Printf("Webhook delivery %d to %s failed permanently: %v", d.ID, webhooks[i].URL, sendErr)
+		case models.DeliveryPending:
+			log.Printf("Webhook delivery %d to %s failed (attempt %d): %v", d.ID, webhooks[i].URL, d.Attempts, sendErr)
+		}
+
+		if err := s.repo.UpdateWebhookDelivery(d); err != nil {
+			return len(deliveries), fmt.Errorf("failed to update webhook delivery: %w", err)
+		}
+	}
+	return len(deliveries), nil
+}
+
+// recordDeliveryAttempt учитывает попытку доставки d с кодом ответа code: успех закрывает доставку,
+// ошибка откладывает следующую попытку по retryBackoff, а после webhookMaxAttempts - переводит в failed
+func recordDeliveryAttempt(d *models.WebhookDelivery, code int, sendErr error, now time.Time) {
+	d.Attempts++
+	d.LastStatusCode = nil
+	if code != 0 {
+		d.LastStatusCode = &code
+	}
+	d.LastError = ""
+	d.NextAttemptAt = nil
+	switch {
+	case sendErr == nil:
+		d.Status = models.DeliveryDelivered
+		d.DeliveredAt = &now
+	case d.Attempts >= webhookMaxAttempts:
+		d.Status = models.DeliveryFailed
+		d.LastError = sendErr.Error()
+	default:
+		next := now.Add(retryBackoff(d.Attempts-1, webhookBaseBackoff, webhookMaxBackoff))
+		d.LastError = sendErr.Error()
+		d.NextAttemptAt = &next
+	}
+}
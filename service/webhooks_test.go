@@ -0,0 +1,68 @@
+package service
+
+import (
+	"avito/models"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestRecordDeliveryAttemptRetries(t *testing.T) {
+	now := time.Date(2025, 3, 1, 9, 0, 0, 0, time.UTC)
+	sendErr := errors.New("webhook endpoint returned status 503")
+	d := &models.WebhookDelivery{ID: 1, Status: models.DeliveryPending}
+
+	for attempt := 1; attempt < webhookMaxAttempts; attempt++ {
+		recordDeliveryAttempt(d, http.StatusServiceUnavailable, sendErr, now)
+		if d.Status != models.DeliveryPending || d.Attempts != attempt {
+			t.Fatalf("attempt %d: status %s, attempts %d", attempt, d.Status, d.Attempts)
+		}
+		want := now.Add(retryBackoff(attempt-1, webhookBaseBackoff, webhookMaxBackoff))
+		if d.NextAttemptAt == nil || !d.NextAttemptAt.Equal(want) {
+			t.Fatalf("attempt %d: next attempt at %v, want %v", attempt, d.NextAttemptAt, want)
+		}
+		if d.LastStatusCode == nil || *d.LastStatusCode != http.StatusServiceUnavailable || d.LastError != sendErr.Error() {
+			t.Fatalf("attempt %d: last status %v, last error %q", attempt, d.LastStatusCode, d.LastError)
+		}
+	}
+
+	recordDeliveryAttempt(d, 0, sendErr, now)
+	if d.Status != models.DeliveryFailed || d.Attempts != webhookMaxAttempts {
+		t.Fatalf("after %d attempts: status %s, attempts %d; want failed", webhookMaxAttempts, d.Status, d.Attempts)
+	}
+	if d.NextAttemptAt != nil || d.LastStatusCode != nil {
+		t.Errorf("failed delivery: next attempt %v, last status %v; want nil", d.NextAttemptAt, d.LastStatusCode)
+	}
+}
+
+func TestRecordDeliveryAttemptSuccess(t *testing.T) {
+	now := time.Date(2025, 3, 1, 9, 0, 0, 0, time.UTC)
+	d := &models.WebhookDelivery{ID: 1, Status: models.DeliveryPending, Attempts: 2, LastError: "timeout"}
+
+	recordDeliveryAttempt(d, http.StatusOK, nil, now)
+	if d.Status != models.DeliveryDelivered || d.Attempts != 3 || d.LastError != "" || d.NextAttemptAt != nil {
+		t.Errorf("delivery = %+v", d)
+	}
+	if d.DeliveredAt == nil || !d.DeliveredAt.Equal(now) {
+		t.Errorf("delivered at %v, want %v", d.DeliveredAt, now)
+	}
+}
+
+func TestRetryBackoff(t *testing.T) {
+	tests := []struct {
+		attempts int
+		want     time.Duration
+	}{
+		{0, 10 * time.Second},
+		{1, 20 * time.Second},
+		{3, 80 * time.Second},
+		{9, time.Hour},
+		{64, time.Hour},
+	}
+	for _, tt := range tests {
+		if got := retryBackoff(tt.attempts, webhookBaseBackoff, webhookMaxBackoff); got != tt.want {
+			t.Errorf("retryBackoff(%d) = %v, want %v", tt.attempts, got, tt.want)
+		}
+	}
+}
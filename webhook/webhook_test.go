@@ -0,0 +1,102 @@
+package webhook
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// Пример из документации GitHub по проверке подписи вебхуков
+const (
+	testSecret    = "It's a Secret to Everybody"
+	testBody      = "Hello, World!"
+	testSignature = "sha256=757107ea0eb2509fc211221cce984b8a37570b6d7586c22c46f4379c8b043e17"
+)
+
+func TestSign(t *testing.T) {
+	if got := Sign(testSecret, []byte(testBody)); got != testSignature {
+		t.Errorf("Sign = %s, want %s", got, testSignature)
+	}
+}
+
+func TestVerify(t *testing.T) {
+	tests := []struct {
+		name      string
+		secret    string
+		body      string
+		signature string
+		want      bool
+	}{
+		{"valid", testSecret, testBody, testSignature, true},
+		{"wrong secret", "other", testBody, testSignature, false},
+		{"modified body", testSecret, testBody + " ", testSignature, false},
+		{"missing prefix", testSecret, testBody, testSignature[len("sha256="):], false},
+		{"empty", testSecret, testBody, "", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Verify(tt.secret, []byte(tt.body), tt.signature); got != tt.want {
+				t.Errorf("Verify = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestClientSend(t *testing.T) {
+	tests := []struct {
+		status  int
+		wantErr bool
+	}{
+		{http.StatusOK, false},
+		{http.StatusNoContent, false},
+		{http.StatusGone, true},
+		{http.StatusServiceUnavailable, true},
+	}
+	for _, tt := range tests {
+		t.Run(http.StatusText(tt.status), func(t *testing.T) {
+			var header http.Header
+			var body string
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				header = r.Header
+				data, _ := io.ReadAll(r.Body)
+				body = string(data)
+				w.WriteHeader(tt.status)
+			}))
+			defer srv.Close()
+
+			code, err := NewClient(nil).Send(context.Background(), Request{
+				URL: srv.URL, Secret: testSecret, DeliveryID: 17, EventType: "pr.merged", Body: []byte(testBody),
+			})
+			if code != tt.status {
+				t.Errorf("code = %d, want %d", code, tt.status)
+			}
+			if (err != nil) != tt.wantErr {
+				t.Errorf("error = %v, want error %v", err, tt.wantErr)
+			}
+			if body != testBody {
+				t.Errorf("body = %q", body)
+			}
+			if got := header.Get(SignatureHeader); got != testSignature {
+				t.Errorf("%s = %q, want %q", SignatureHeader, got, testSignature)
+			}
+			if header.Get("X-Event-Type") != "pr.merged" || header.Get("X-Delivery-ID") != "17" {
+				t.Errorf("event headers = %q, %q", header.Get("X-Event-Type"), header.Get("X-Delivery-ID"))
+			}
+			if header.Get("Content-Type") != "application/json" {
+				t.Errorf("Content-Type = %q", header.Get("Content-Type"))
+			}
+		})
+	}
+}
+
+func TestClientSendNetworkError(t *testing.T) {
+	srv := httptest.NewServer(http.NotFoundHandler())
+	srv.Close()
+
+	code, err := NewClient(nil).Send(context.Background(), Request{URL: srv.URL, Secret: testSecret, Body: []byte(testBody)})
+	if code != 0 || err == nil {
+		t.Errorf("Send = %d, %v; want 0 and an error", code, err)
+	}
+}